@@ -2,12 +2,14 @@ package commands
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/mjavint/ocli/pkg/addons"
 	"github.com/mjavint/ocli/pkg/config"
 	"github.com/spf13/cobra"
 )
@@ -15,36 +17,117 @@ import (
 // initCmd represents the init command
 func NewConfigAddonCmd() *cobra.Command {
 	var (
-		addons []string
+		configPath string
+		roots      []string
+		maxDepth   int
+		dryRun     bool
 	)
 	cmd := &cobra.Command{
 		Use:   "addon",
 		Short: "Configuration Addon in projects",
-		Long:  `Configuration Addon in projects`,
+		Long: `Configuration Addon in projects.
+
+Scan the given roots (default: addons from ocli.yml) recursively for
+directories containing __manifest__.py, compute the minimal set of parent
+directories forming the addons_path, and write it to odoo.conf and
+pyrightconfig.json. Modules marked as not installable are skipped, and
+duplicate module names are reported with the copy that wins by order.
+
+Example:
+  ocli addon
+  ocli addon -p /workspace/odoo -p /workspace/repos --dry-run`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(addons) == 0 {
-				addons = config.AppConfig.Odoo.Addons
+			if len(roots) == 0 {
+				roots = config.AppConfig.Odoo.Addons
 			}
-			addonsPath := strings.Join(addons, ",")
-			if err := updateOdooConf(config.AppConfig.Odoo.ConfigFile, addonsPath); err != nil {
-				log.Fatal("failed to update odoo.conf: %w", err)
+			if len(roots) == 0 {
+				log.Fatal("No directories to scan. Use --path or -p, or set odoo.addons in ocli.yml.")
 			}
-			// Obtener directorio actual (funciona en todos los SO)
-			dir, err := os.Getwd()
+			discovery, err := addons.Discover(roots, maxDepth)
 			if err != nil {
-				panic(fmt.Sprintf("Error obteniendo directorio actual: %v", err))
+				log.Fatalf("failed to discover addons: %v", err)
 			}
-			pyrightConfigPath := filepath.Join(dir, "pyrightconfig.json")
-			if err := updatePyrightConfig(pyrightConfigPath, addonsPath); err != nil {
-				log.Fatal("failed to update pyrightconfig.json: %w", err)
+			printDiscovery(discovery)
+			if dryRun {
+				return
+			}
+
+			if err := applyAddonsPath(configPath, discovery); err != nil {
+				log.Fatal(err)
 			}
-			fmt.Printf("📦 Addons paths detectados (%d total):\n", len(addons))
-			fmt.Printf("Successfully created %s\n", addonsPath)
 		},
 	}
+	cmd.Flags().StringVarP(&configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.Flags().StringSliceVarP(&roots, "path", "p", nil, "Directories to scan for modules (default: addons from ocli.yml)")
+	cmd.Flags().IntVar(&maxDepth, "max-depth", addons.DefaultMaxDepth, "Maximum directory depth to scan below each root")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only print the discovered addons paths")
 	return cmd
 }
 
+// applyAddonsPath writes the discovered addons paths to odoo.conf and
+// pyrightconfig.json in the current directory
+func applyAddonsPath(configPath string, discovery *addons.Discovery) error {
+	return writeAddonsPath(configPath, discovery.Paths)
+}
+
+// writeAddonsPath sets the addons_path of odoo.conf and pyrightconfig.json
+// in the current directory to paths
+func writeAddonsPath(configPath string, paths []string) error {
+	if configPath == "" {
+		configPath = config.AppConfig.Odoo.ConfigFile
+	}
+	addonsPath := strings.Join(paths, ",")
+	if err := updateOdooConf(configPath, addonsPath); err != nil {
+		return fmt.Errorf("failed to update odoo.conf: %w", err)
+	}
+	// Obtener directorio actual (funciona en todos los SO)
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("error obteniendo directorio actual: %w", err)
+	}
+	pyrightConfigPath := filepath.Join(dir, "pyrightconfig.json")
+	if err := updatePyrightConfig(pyrightConfigPath, addonsPath); err != nil {
+		return fmt.Errorf("failed to update pyrightconfig.json: %w", err)
+	}
+	fmt.Printf("Successfully created %s\n", addonsPath)
+	return nil
+}
+
+// printDiscovery reports the addons paths, duplicates and skipped modules
+func printDiscovery(d *addons.Discovery) {
+	counts := make(map[string]int)
+	for _, mod := range d.Modules {
+		counts[mod.AddonsPath]++
+	}
+	fmt.Printf("📦 Addons paths detectados (%d total, %d modules):\n", len(d.Paths), len(d.Modules))
+	for _, p := range d.Paths {
+		fmt.Printf("  - %s (%d)\n", p, counts[p])
+	}
+	if len(d.Duplicates) > 0 {
+		fmt.Printf("\n⚠️ Duplicate modules (%d):\n", len(d.Duplicates))
+		for _, dup := range d.Duplicates {
+			fmt.Printf("  - %s: using %s\n", dup.Name, dup.Winner.Dir)
+			for _, shadowed := range dup.Shadowed {
+				fmt.Printf("      ignored %s\n", shadowed.Dir)
+			}
+		}
+	}
+	if len(d.Unparsed) > 0 {
+		fmt.Printf("\n⚠️ Unreadable manifests (%d), modules kept in the path:\n", len(d.Unparsed))
+		for _, mod := range d.Unparsed {
+			fmt.Printf("  - %s: %v\n", mod.Dir, mod.Err)
+		}
+	}
+	if len(d.Skipped) > 0 {
+		names := make([]string, len(d.Skipped))
+		for i, mod := range d.Skipped {
+			names[i] = mod.Name
+		}
+		fmt.Printf("\nSkipped %d non-installable module(s): %s\n", len(names), strings.Join(names, ", "))
+	}
+	fmt.Println()
+}
+
 // updateOdooConf updates the addons_path in odoo.conf file
 func updateOdooConf(odooConfPath, newAddonsPath string) error {
 	content, err := os.ReadFile(odooConfPath)
@@ -75,16 +158,16 @@ func updateOdooConf(odooConfPath, newAddonsPath string) error {
 
 // updatePyrightConfig updates the extraPaths array in pyrightconfig.json
 func updatePyrightConfig(pyrightConfigPath, newAddonsPath string) error {
+	config := make(map[string]interface{})
 	content, err := os.ReadFile(pyrightConfigPath)
-	if err != nil {
+	if err == nil {
+		if err := json.Unmarshal(content, &config); err != nil {
+			return fmt.Errorf("failed to parse pyrightconfig.json: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
 		return fmt.Errorf("failed to read pyrightconfig.json: %w", err)
 	}
 
-	var config map[string]interface{}
-	if err := json.Unmarshal(content, &config); err != nil {
-		return fmt.Errorf("failed to parse pyrightconfig.json: %w", err)
-	}
-
 	// Convert comma-separated addons path to slice
 	addonPaths := strings.Split(newAddonsPath, ",")
 	cleanPaths := make([]string, 0, len(addonPaths))
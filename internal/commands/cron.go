@@ -0,0 +1,213 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mjavint/ocli/pkg/config"
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/odoo"
+	"github.com/mjavint/ocli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// cronRunScript is run in odoo-bin shell; %d is the cron id
+const cronRunScript = `
+cron = env['ir.cron'].browse(%d).exists()
+if not cron:
+    raise SystemExit('Cron %d not found')
+cron.method_direct_trigger()
+env.cr.commit()
+`
+
+// NewCronCmd represents the cron command
+func NewCronCmd() *cobra.Command {
+	opts := &dbOptions{}
+	cmd := &cobra.Command{
+		Use:   "cron",
+		Short: "List, enable, disable and run scheduled actions (ir_cron)",
+		Long: `Manage the scheduled actions of a database, e.g. to keep a few crons
+active on a neutralized staging copy or to debug one scheduled action.
+
+Crons are selected by id or by name: a pattern with * or ? is matched
+against the whole name, anything else matches as a substring; both are
+case-insensitive.`,
+	}
+	cmd.PersistentFlags().StringVarP(&opts.configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.PersistentFlags().StringVarP(&opts.dbName, "database", "d", "", "Database name")
+
+	cmd.AddCommand(
+		newCronListCmd(opts),
+		newCronToggleCmd(opts, true),
+		newCronToggleCmd(opts, false),
+		newCronRunCmd(opts),
+	)
+	return cmd
+}
+
+func newCronListCmd(opts *dbOptions) *cobra.Command {
+	var (
+		format     string
+		activeOnly bool
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List scheduled actions",
+		Run: func(cmd *cobra.Command, args []string) {
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+			crons, err := db.ListCrons(cmd.Context(), conn)
+			if err != nil {
+				log.Fatal(err)
+			}
+			table := output.NewTable("ID", "Name", "Model", "Method", "Interval", "Next call", "Active", "Last failure")
+			for _, c := range crons {
+				if activeOnly && !c.Active {
+					continue
+				}
+				table.AddRow(c.ID, c.Name, orDash(c.Model), orDash(cronMethod(c.Code)),
+					fmt.Sprintf("%d %s", c.IntervalNumber, c.IntervalType),
+					c.NextCall.Format(time.DateTime), yesNo(c.Active), cronFailure(c))
+			}
+			if err := output.Render(os.Stdout, format, table); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	output.AddFormatFlag(cmd, &format)
+	cmd.Flags().BoolVar(&activeOnly, "active", false, "Only list active crons")
+	return cmd
+}
+
+func newCronToggleCmd(opts *dbOptions, active bool) *cobra.Command {
+	use, short, verb := "enable", "Activate scheduled actions", "Enabled"
+	if !active {
+		use, short, verb = "disable", "Deactivate scheduled actions", "Disabled"
+	}
+	return &cobra.Command{
+		Use:   use + " id|name-pattern [...]",
+		Short: short,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+			crons, err := db.ListCrons(ctx, conn)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			var ids []int64
+			seen := make(map[int64]bool)
+			for _, selector := range args {
+				matched := matchCrons(crons, selector)
+				if len(matched) == 0 {
+					log.Fatalf("No cron matches %q", selector)
+				}
+				for _, c := range matched {
+					if !seen[c.ID] {
+						seen[c.ID] = true
+						ids = append(ids, c.ID)
+						fmt.Printf("  %d %s\n", c.ID, c.Name)
+					}
+				}
+			}
+			changed, err := db.SetCronsActive(ctx, conn, ids, active)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("✅ %s %d cron(s), %d already %sd\n", verb, changed, int64(len(ids))-changed, use)
+		},
+	}
+}
+
+func newCronRunCmd(opts *dbOptions) *cobra.Command {
+	var (
+		odooBin string
+		quiet   bool
+	)
+	cmd := &cobra.Command{
+		Use:   "run id",
+		Short: "Run a scheduled action now through odoo-bin shell",
+		Long: `Run one scheduled action immediately with method_direct_trigger in
+odoo-bin shell, whether it is active or not, and commit its changes.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				log.Fatalf("Invalid cron id: %s", args[0])
+			}
+			if opts.dbName == "" {
+				log.Fatal("Database name is required. Use --database or -d to specify it.")
+			}
+			if odooBin == "" {
+				odooBin = config.AppConfig.Odoo.OdooBin
+			}
+			configPath := opts.configPath
+			if configPath == "" {
+				configPath = config.AppConfig.Odoo.ConfigFile
+			}
+
+			script := fmt.Sprintf(cronRunScript, id, id)
+			shellArgs := []string{"-c", configPath, "-d", opts.dbName, "--logfile="}
+			fmt.Printf("Executing: %s shell %v\n", odooBin, shellArgs)
+			start := time.Now()
+			result, err := odoo.RunShell(cmd.Context(), odooBin, shellArgs, script, odooEcho(quiet))
+			if err := reportOdooRun(fmt.Sprintf("Cron %d", id), start, result, err); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&odooBin, "bin", "b", "", "Path to the Odoo binary")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Do not echo the Odoo output")
+	return cmd
+}
+
+// matchCrons selects crons by id, glob pattern or name substring
+func matchCrons(crons []db.Cron, selector string) []db.Cron {
+	if id, err := strconv.ParseInt(selector, 10, 64); err == nil {
+		for _, c := range crons {
+			if c.ID == id {
+				return []db.Cron{c}
+			}
+		}
+		return nil
+	}
+	pattern := strings.ToLower(selector)
+	glob := strings.ContainsAny(pattern, "*?[")
+	var matched []db.Cron
+	for _, c := range crons {
+		name := strings.ToLower(c.Name)
+		if glob {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				matched = append(matched, c)
+			}
+		} else if strings.Contains(name, pattern) {
+			matched = append(matched, c)
+		}
+	}
+	return matched
+}
+
+// cronMethod returns the first line of the server action code, which is
+// usually the model method called, e.g. model._cron_process()
+func cronMethod(code string) string {
+	for _, line := range strings.Split(code, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+func cronFailure(c db.Cron) string {
+	if c.FailureCount == 0 || c.FirstFailure == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d since %s", c.FailureCount, c.FirstFailure.Format(time.DateTime))
+}
@@ -0,0 +1,267 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// queryWidth is how much of each query the activity tables show
+const queryWidth = 60
+
+func newDbActivityCmd(opts *dbOptions) *cobra.Command {
+	var (
+		hideIdle bool
+		format   string
+	)
+	cmd := &cobra.Command{
+		Use:   "activity",
+		Short: "Show the sessions connected to a database and who blocks whom",
+		Long: `Show the sessions of pg_stat_activity connected to a database: their
+application (the Odoo workers name themselves after the database), state,
+current query and how long it has been running.
+
+Sessions waiting for a lock are followed by the chains of sessions
+blocking them, the first of each chain being the one to look at.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+			sessions, err := db.ListActivity(cmd.Context(), conn, opts.dbName)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if hideIdle {
+				active := sessions[:0]
+				for _, a := range sessions {
+					if a.State != "idle" {
+						active = append(active, a)
+					}
+				}
+				sessions = active
+			}
+			if len(sessions) == 0 {
+				fmt.Fprintf(os.Stderr, "✅ No other session connected to %s\n", opts.dbName)
+				return
+			}
+			if err := output.Render(os.Stdout, format, activityTable(sessions)); err != nil {
+				log.Fatal(err)
+			}
+			if format == output.FormatTable {
+				printLockChains(os.Stdout, sessions)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&hideIdle, "active", false, "Hide the idle sessions (idle in transaction ones are kept)")
+	output.AddFormatFlag(cmd, &format)
+	return cmd
+}
+
+func newDbKillCmd(opts *dbOptions) *cobra.Command {
+	var (
+		pids      []int64
+		idleLimit time.Duration
+		yes       bool
+	)
+	cmd := &cobra.Command{
+		Use:   "kill",
+		Short: "Terminate selected sessions of a database",
+		Long: `Terminate the sessions of a database given by --pid, or the ones idle
+for longer than --idle-longer-than, e.g. a forgotten shell holding a
+transaction open. Only sessions connected to --database are considered.
+
+Example:
+  ocli db kill -d database_name --pid 4242
+  ocli db kill -d database_name --idle-longer-than 10m`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(pids) == 0 && idleLimit == 0 {
+				log.Fatal("Select the sessions with --pid or --idle-longer-than")
+			}
+			ctx := cmd.Context()
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+			sessions, err := db.ListActivity(ctx, conn, opts.dbName)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			wanted := make(map[int64]bool, len(pids))
+			for _, pid := range pids {
+				wanted[pid] = true
+			}
+			var selected []db.Activity
+			for _, a := range sessions {
+				if wanted[a.PID] || (idleLimit > 0 && a.Idle() && a.StateAge > idleLimit) {
+					selected = append(selected, a)
+					delete(wanted, a.PID)
+				}
+			}
+			for pid := range wanted {
+				log.Fatalf("Session %d is not connected to %s", pid, opts.dbName)
+			}
+			if len(selected) == 0 {
+				fmt.Printf("✅ No session of %s idle for longer than %s\n", opts.dbName, idleLimit)
+				return
+			}
+
+			output.Render(os.Stdout, output.FormatTable, activityTable(selected))
+			if !confirm(fmt.Sprintf("Terminate %d session(s)?", len(selected)), yes) {
+				fmt.Println("Aborted, nothing changed.")
+				return
+			}
+			ids := make([]int64, len(selected))
+			for i, a := range selected {
+				ids[i] = a.PID
+			}
+			terminated, err := db.TerminateBackends(ctx, conn, ids)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("✅ Terminated %d session(s)", len(terminated))
+			if gone := len(ids) - len(terminated); gone > 0 {
+				fmt.Printf(", %d had already ended", gone)
+			}
+			fmt.Println()
+		},
+	}
+	cmd.Flags().Int64SliceVar(&pids, "pid", nil, "Session to terminate (repeatable)")
+	cmd.Flags().DurationVar(&idleLimit, "idle-longer-than", 0, "Terminate the sessions idle for longer than this, e.g. 10m")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not ask for confirmation")
+	return cmd
+}
+
+// activityTable renders sessions with their ages and truncated queries
+func activityTable(sessions []db.Activity) *output.Table {
+	table := output.NewTable("PID", "Application", "User", "Client", "State", "Since", "Query age", "Waiting", "Query")
+	for _, a := range sessions {
+		waiting := orDash(a.WaitEvent)
+		if len(a.BlockedBy) > 0 {
+			waiting = fmt.Sprintf("blocked by %s", joinPids(a.BlockedBy))
+		}
+		queryAge := "-"
+		if a.State != "idle" && a.QueryAge > 0 {
+			queryAge = formatAge(a.QueryAge)
+		}
+		table.AddRow(a.PID, orDash(a.Application), a.User, a.ClientAddr, orDash(a.State),
+			formatAge(a.StateAge), queryAge, waiting, orDash(truncateQuery(a.Query, queryWidth)))
+	}
+	return table
+}
+
+// printLockChains prints, for each session blocking others without being
+// blocked itself, the tree of sessions waiting for it
+func printLockChains(w io.Writer, sessions []db.Activity) {
+	byPid := make(map[int64]db.Activity, len(sessions))
+	waiters := make(map[int64][]int64)
+	blocked := make(map[int64]bool)
+	for _, a := range sessions {
+		byPid[a.PID] = a
+		for _, blocker := range a.BlockedBy {
+			waiters[blocker] = append(waiters[blocker], a.PID)
+			blocked[a.PID] = true
+		}
+	}
+	if len(waiters) == 0 {
+		return
+	}
+
+	var roots []int64
+	for blocker := range waiters {
+		if !blocked[blocker] {
+			roots = append(roots, blocker)
+		}
+	}
+	if len(roots) == 0 {
+		// Every blocker waits for another one: a deadlock in progress,
+		// start anywhere
+		for blocker := range waiters {
+			roots = append(roots, blocker)
+		}
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i] < roots[j] })
+
+	fmt.Fprintln(w, "\n🔒 Lock chains:")
+	seen := make(map[int64]bool)
+	var walk func(pid int64, depth int)
+	walk = func(pid int64, depth int) {
+		indent := strings.Repeat("   ", depth)
+		if seen[pid] {
+			fmt.Fprintf(w, "  %s└─ %d (cycle)\n", indent, pid)
+			return
+		}
+		seen[pid] = true
+		prefix := ""
+		if depth > 0 {
+			prefix = "└─ "
+		}
+		if a, ok := byPid[pid]; ok {
+			fmt.Fprintf(w, "  %s%s%d %s [%s %s] %s\n", indent, prefix, pid, orDash(a.Application), orDash(a.State),
+				formatAge(a.StateAge), truncateQuery(a.Query, queryWidth))
+		} else {
+			fmt.Fprintf(w, "  %s%s%d (other database)\n", indent, prefix, pid)
+		}
+		children := waiters[pid]
+		sort.Slice(children, func(i, j int) bool { return children[i] < children[j] })
+		for _, child := range children {
+			walk(child, depth+1)
+		}
+	}
+	for _, root := range roots {
+		if !seen[root] {
+			walk(root, 0)
+		}
+	}
+}
+
+// showConnections lists the sessions connected to dbName before an
+// operation that terminates them
+func showConnections(ctx context.Context, configPath, dbName string) {
+	cfg, err := loadPGConfig(configPath)
+	if err != nil {
+		return
+	}
+	conn, err := db.Connect(ctx, "postgres", cfg)
+	if err != nil {
+		return
+	}
+	defer db.CloseDB(conn)
+	sessions, err := db.ListActivity(ctx, conn, dbName)
+	if err != nil || len(sessions) == 0 {
+		return
+	}
+	fmt.Printf("⚠️ %d session(s) connected to %s will be terminated:\n", len(sessions), dbName)
+	output.Render(os.Stdout, output.FormatTable, activityTable(sessions))
+}
+
+func joinPids(pids []int64) string {
+	parts := make([]string, len(pids))
+	for i, pid := range pids {
+		parts[i] = fmt.Sprint(pid)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatAge renders a duration to the second, or "-" when unknown
+func formatAge(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	return d.Round(time.Second).String()
+}
+
+// truncateQuery collapses the whitespace of a query and cuts it to width
+func truncateQuery(query string, width int) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if r := []rune(query); len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	return query
+}
@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// NewDbCmd represents the db command
+func NewDbCmd() *cobra.Command {
+	opts := &dbOptions{}
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Inspect the PostgreSQL side of an Odoo database",
+		Long: `Inspect the PostgreSQL side of an Odoo database: the size of its tables
+and indexes, how much of them is dead tuples, the sessions connected to
+it with the locks they hold, and run its routine maintenance.`,
+	}
+	cmd.PersistentFlags().StringVarP(&opts.configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.PersistentFlags().StringVarP(&opts.dbName, "database", "d", "", "Database name")
+
+	cmd.AddCommand(
+		newDbStatsCmd(opts),
+		newDbActivityCmd(opts),
+		newDbKillCmd(opts),
+		newDbMaintainCmd(opts),
+	)
+	return cmd
+}
+
+func newDbStatsCmd(opts *dbOptions) *cobra.Command {
+	var (
+		top     int
+		sortBy  string
+		indexes bool
+		format  string
+	)
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show the largest tables and indexes with their models",
+		Long: `Show the largest tables of a database with their heap, TOAST (large
+values such as attachments or HTML fields) and index sizes, the estimated
+number of rows and the dead tuples left by updates and deletes. Tables
+are mapped back to their Odoo model through ir_model; models of custom
+modules that set their own _table are not recognized and show no model.
+
+The bloat ratio is the share of dead tuples; a high one on a large table
+calls for 'ocli db maintain'.
+
+Example:
+  ocli db stats -d database_name
+  ocli db stats -d database_name --sort bloat -n 10
+  ocli db stats -d database_name --indexes`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sortTableStats(nil, sortBy); err != nil {
+				log.Fatal(err)
+			}
+			ctx := cmd.Context()
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+
+			size, err := db.CurrentDatabaseSize(ctx, conn)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Fprintf(os.Stderr, "📦 %s: %s\n", opts.dbName, db.FormatBytes(size))
+
+			if indexes {
+				stats, err := db.IndexStats(ctx, conn)
+				if err != nil {
+					log.Fatal(err)
+				}
+				table := output.NewTable("Index", "Table", "Size", "Scans")
+				for i, s := range stats {
+					if top > 0 && i >= top {
+						break
+					}
+					table.AddRow(s.Name, s.Table, db.FormatBytes(s.Size), s.Scans)
+				}
+				if err := output.Render(os.Stdout, format, table); err != nil {
+					log.Fatal(err)
+				}
+				return
+			}
+
+			stats, err := db.TableStats(ctx, conn)
+			if err != nil {
+				log.Fatal(err)
+			}
+			sortTableStats(stats, sortBy)
+			table := output.NewTable("Table", "Model", "Total", "Heap", "TOAST", "Indexes", "Rows", "Dead", "Bloat")
+			for i, t := range stats {
+				if top > 0 && i >= top {
+					break
+				}
+				rows := "-"
+				if t.EstimatedRows >= 0 {
+					rows = fmt.Sprint(t.EstimatedRows)
+				}
+				table.AddRow(t.Table, orDash(t.Model), db.FormatBytes(t.TotalSize), db.FormatBytes(t.HeapSize),
+					db.FormatBytes(t.ToastSize), db.FormatBytes(t.IndexSize), rows, t.DeadTuples,
+					fmt.Sprintf("%.1f%%", t.BloatRatio()*100))
+			}
+			if err := output.Render(os.Stdout, format, table); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	cmd.Flags().IntVarP(&top, "top", "n", 20, "Number of tables or indexes to show (0 for all)")
+	cmd.Flags().StringVar(&sortBy, "sort", "size", "Sort tables by size, rows, dead or bloat")
+	cmd.Flags().BoolVar(&indexes, "indexes", false, "Show the largest indexes instead of tables")
+	output.AddFormatFlag(cmd, &format)
+	return cmd
+}
+
+// sortTableStats orders the tables by the given key, largest first
+func sortTableStats(stats []db.TableStat, by string) error {
+	var key func(t db.TableStat) float64
+	switch by {
+	case "size":
+		key = func(t db.TableStat) float64 { return float64(t.TotalSize) }
+	case "rows":
+		key = func(t db.TableStat) float64 { return float64(t.EstimatedRows) }
+	case "dead":
+		key = func(t db.TableStat) float64 { return float64(t.DeadTuples) }
+	case "bloat":
+		key = func(t db.TableStat) float64 { return t.BloatRatio() }
+	default:
+		return fmt.Errorf("invalid --sort value %q, use size, rows, dead or bloat", by)
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return key(stats[i]) > key(stats[j]) })
+	return nil
+}
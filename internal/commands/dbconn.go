@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/mjavint/ocli/pkg/addons"
+	"github.com/mjavint/ocli/pkg/config"
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/spf13/cobra"
+)
+
+// loadPGConfig builds the PostgreSQL configuration from odoo.conf
+func loadPGConfig(configPath string) (*db.PGConfig, error) {
+	if configPath == "" {
+		configPath = config.AppConfig.Odoo.ConfigFile
+	}
+	dbConfig, err := config.LoadOdooDBParams(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return &db.PGConfig{
+		Host:     dbConfig.Host,
+		Port:     dbConfig.Port,
+		User:     dbConfig.User,
+		Password: dbConfig.Password,
+	}, nil
+}
+
+// connectOdooDB opens a connection to dbName with the odoo.conf credentials
+func connectOdooDB(ctx context.Context, configPath, dbName string) (*sql.DB, error) {
+	pgCfg, err := loadPGConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return db.Connect(ctx, dbName, pgCfg)
+}
+
+// resolveAddonsPaths returns the addons_path of odoo.conf, falling back to
+// the addons listed in ocli.yml
+func resolveAddonsPaths(configPath string) []string {
+	if configPath == "" {
+		configPath = config.AppConfig.Odoo.ConfigFile
+	}
+	if options, err := config.ReadOdooConf(configPath); err == nil {
+		if paths := addons.SplitPaths(confValue(options, "addons_path")); len(paths) > 0 {
+			return paths
+		}
+	}
+	return config.AppConfig.Odoo.Addons
+}
+
+// coreAddonsPath returns the odoo/addons directory next to odoo-bin, which
+// holds base and is always loaded by Odoo even if not in addons_path
+func coreAddonsPath() string {
+	if config.AppConfig.Odoo.OdooBin == "" {
+		return ""
+	}
+	dir := filepath.Join(filepath.Dir(config.AppConfig.Odoo.OdooBin), "odoo", "addons")
+	if _, err := os.Stat(dir); err != nil {
+		return ""
+	}
+	return dir
+}
+
+// filestoreDir returns the filestore directory of dbName per odoo.conf
+func filestoreDir(configPath, dbName string) string {
+	if configPath == "" {
+		configPath = config.AppConfig.Odoo.ConfigFile
+	}
+	options, _ := config.ReadOdooConf(configPath)
+	return filepath.Join(config.OdooDataDir(options), "filestore", dbName)
+}
+
+// dbOptions are the -c and -d flags shared by the subcommands of a
+// command working on one database
+type dbOptions struct {
+	configPath string
+	dbName     string
+}
+
+// connect opens --database, failing when it was not given
+func (opts *dbOptions) connect(cmd *cobra.Command) *sql.DB {
+	if opts.dbName == "" {
+		log.Fatal("Database name is required. Use --database or -d to specify it.")
+	}
+	conn, err := connectOdooDB(cmd.Context(), opts.configPath, opts.dbName)
+	if err != nil {
+		log.Fatalf("Error connecting to %s: %v", opts.dbName, err)
+	}
+	return conn
+}
@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+func newDbMaintainCmd(opts *dbOptions) *cobra.Command {
+	var (
+		all         bool
+		maint       db.MaintenanceOptions
+		top         int
+		minBloat    float64
+		format      string
+		changedOnly bool
+	)
+	cmd := &cobra.Command{
+		Use:   "maintain",
+		Short: "Vacuum, analyze and reindex the tables of one or all databases",
+		Long: `Run VACUUM, ANALYZE and REINDEX on the tables of a database, or of every
+initialized Odoo database with --all, and report the size of each table
+before and after and how long it took. Without any of --vacuum, --analyze
+and --reindex, tables are vacuumed and analyzed.
+
+It is safe to run unattended, e.g. from cron: each statement waits at most
+--lock-timeout for its table lock and the table is skipped otherwise, so
+Odoo workers are never queued behind maintenance for long. --full rewrites
+the tables to give the space back to the system but locks each table
+while it runs; REINDEX uses CONCURRENTLY on PostgreSQL 12 and later, and
+when it times out the invalid indexes it was building are dropped.
+
+Example:
+  ocli db maintain -d database_name
+  ocli db maintain -d database_name --vacuum --full --top 5
+  ocli db maintain --all --analyze`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !maint.Vacuum && !maint.Analyze && !maint.Reindex && !maint.Full {
+				maint.Vacuum, maint.Analyze = true, true
+			}
+			ctx := cmd.Context()
+
+			var databases []string
+			switch {
+			case all:
+				pgCfg, err := loadPGConfig(opts.configPath)
+				if err != nil {
+					log.Fatal(err)
+				}
+				if databases, err = db.ListInitializedDatabases(ctx, pgCfg); err != nil {
+					log.Fatalf("Error listing databases: %v", err)
+				}
+			case opts.dbName != "":
+				databases = []string{opts.dbName}
+			default:
+				log.Fatal("Database name is required. Use --database or -d to specify it, or --all.")
+			}
+
+			table := output.NewTable("Database", "Table", "Before", "After", "Saved", "Duration", "Status")
+			failed, failedDBs := 0, 0
+			for _, name := range databases {
+				conn, err := connectOdooDB(ctx, opts.configPath, name)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "🔴 Error connecting to %s: %v\n", name, err)
+					table.AddRow(name, "-", "-", "-", "-", "-", err.Error())
+					failedDBs++
+					continue
+				}
+				results, err := maintainDatabase(ctx, conn, maint, top, minBloat)
+				db.CloseDB(conn)
+				if err != nil {
+					// Report the tables done before the error, then go on
+					// with the other databases
+					fmt.Fprintf(os.Stderr, "🔴 Error maintaining %s: %v\n", name, err)
+					table.AddRow(name, "-", "-", "-", "-", "-", err.Error())
+					failedDBs++
+					if ctx.Err() != nil {
+						break
+					}
+				}
+
+				var before, after int64
+				var took time.Duration
+				for _, r := range results {
+					before += r.SizeBefore
+					after += r.SizeAfter
+					took += r.Duration
+					status := "ok"
+					switch {
+					case r.Err != nil:
+						status = r.Err.Error()
+						failed++
+					case r.Skipped:
+						status = "skipped, table busy"
+					}
+					if changedOnly && status == "ok" && r.SizeBefore == r.SizeAfter {
+						continue
+					}
+					saved := "-"
+					if r.SizeBefore > r.SizeAfter {
+						saved = db.FormatBytes(r.SizeBefore - r.SizeAfter)
+					}
+					table.AddRow(name, r.Table, db.FormatBytes(r.SizeBefore), db.FormatBytes(r.SizeAfter),
+						saved, r.Duration.Round(time.Millisecond), status)
+				}
+				fmt.Fprintf(os.Stderr, "🧹 %s: %d table(s), %s -> %s in %s\n", name, len(results),
+					db.FormatBytes(before), db.FormatBytes(after), took.Round(time.Second))
+			}
+
+			if err := output.Render(os.Stdout, format, table); err != nil {
+				log.Fatal(err)
+			}
+			if failed > 0 {
+				fmt.Fprintf(os.Stderr, "🔴 %d table(s) failed\n", failed)
+			}
+			if failedDBs > 0 {
+				fmt.Fprintf(os.Stderr, "🔴 %d database(s) failed\n", failedDBs)
+			}
+			if failed > 0 || failedDBs > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "Maintain every initialized Odoo database")
+	cmd.Flags().BoolVar(&maint.Vacuum, "vacuum", false, "Run VACUUM")
+	cmd.Flags().BoolVar(&maint.Analyze, "analyze", false, "Run ANALYZE")
+	cmd.Flags().BoolVar(&maint.Reindex, "reindex", false, "Run REINDEX")
+	cmd.Flags().BoolVar(&maint.Full, "full", false, "Run VACUUM FULL, locking each table while it is rewritten")
+	cmd.Flags().DurationVar(&maint.LockTimeout, "lock-timeout", 5*time.Second, "Longest wait for a table lock before skipping the table")
+	cmd.Flags().IntVarP(&top, "top", "n", 0, "Only maintain the N most bloated tables (0 for all)")
+	cmd.Flags().Float64Var(&minBloat, "min-bloat", 0, "With --top, ignore tables with a lower bloat ratio, e.g. 0.2")
+	cmd.Flags().BoolVar(&changedOnly, "changed-only", false, "Only report the tables whose size changed or that failed")
+	output.AddFormatFlag(cmd, &format)
+	return cmd
+}
+
+// maintainDatabase runs the maintenance on the tables of one database, the
+// most bloated ones first
+func maintainDatabase(ctx context.Context, conn *sql.DB, maint db.MaintenanceOptions, top int, minBloat float64) ([]db.MaintenanceResult, error) {
+	stats, err := db.TableStats(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	sortTableStats(stats, "bloat")
+	if top > 0 {
+		selected := stats[:0]
+		for _, t := range stats {
+			if len(selected) == top {
+				break
+			}
+			if t.DeadTuples > 0 && t.BloatRatio() >= minBloat {
+				selected = append(selected, t)
+			}
+		}
+		stats = selected
+	}
+
+	m, err := db.NewMaintainer(ctx, conn, maint)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	results := make([]db.MaintenanceResult, 0, len(stats))
+	for _, t := range stats {
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		results = append(results, m.Maintain(ctx, t.Table))
+	}
+	return results, nil
+}
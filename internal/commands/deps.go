@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/mjavint/ocli/pkg/addons"
+	"github.com/spf13/cobra"
+)
+
+// NewDepsCmd represents the deps command
+func NewDepsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deps",
+		Short: "Collect the external dependencies of the modules",
+	}
+	cmd.AddCommand(newDepsPythonCmd())
+	return cmd
+}
+
+func newDepsPythonCmd() *cobra.Command {
+	var (
+		configPath string
+		dbName     string
+		outFile    string
+		noFiles    bool
+	)
+	cmd := &cobra.Command{
+		Use:   "python",
+		Short: "Build a requirements file from the modules' external_dependencies",
+		Long: `Collect the python and bin external_dependencies of every installable
+module in the addons paths, or only of the modules installed in --database.
+They are merged with the requirements.txt files found in the addons paths
+and their git repositories, deduplicated, and written as a pip
+requirements file. Compatible version constraints of a package are
+combined, while constraints no version satisfies together are reported as
+conflicts; lines with different environment markers, such as one pin per
+python_version, are kept apart. The bin dependencies not found in PATH are
+listed as missing system binaries.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			modules := loadLocalModules(configPath)
+			var installed map[string]bool
+			if dbName != "" {
+				installed = (&dbOptions{configPath: configPath, dbName: dbName}).installedModules(cmd)
+			}
+
+			reqs := addons.NewRequirementSet()
+			if !noFiles {
+				for _, file := range addons.FindRequirementsFiles(resolveAddonsPaths(configPath)) {
+					lines, err := addons.ReadRequirementsFile(file)
+					if err != nil {
+						log.Fatalf("Error reading %s: %v", file, err)
+					}
+					for _, line := range lines {
+						reqs.Add(line, file)
+					}
+				}
+			}
+
+			bins := make(map[string][]string)
+			for _, mod := range modules {
+				if !mod.Installable() || (installed != nil && !installed[mod.Name]) {
+					continue
+				}
+				for _, spec := range mod.Manifest.ExternalDependencies["python"] {
+					reqs.Add(spec, mod.Name)
+				}
+				for _, bin := range mod.Manifest.ExternalDependencies["bin"] {
+					bins[bin] = append(bins[bin], mod.Name)
+				}
+			}
+
+			w := io.Writer(os.Stdout)
+			if outFile != "" && outFile != "-" {
+				f, err := os.Create(outFile)
+				if err != nil {
+					log.Fatalf("Error creating %s: %v", outFile, err)
+				}
+				defer f.Close()
+				w = f
+			}
+			if err := writeRequirements(w, reqs); err != nil {
+				log.Fatal(err)
+			}
+			if w != io.Writer(os.Stdout) {
+				fmt.Fprintf(os.Stderr, "✅ %d requirement(s) written to %s\n", len(reqs.Requirements()), outFile)
+			}
+
+			for _, line := range reqs.Unparsed() {
+				fmt.Fprintf(os.Stderr, "⚠️ Ignored requirement: %s\n", line)
+			}
+			for _, conflict := range reqs.Conflicts() {
+				fmt.Fprintf(os.Stderr, "🔴 Conflicting requirements for %s:\n", conflict.Name)
+				for _, req := range conflict.Specs {
+					fmt.Fprintf(os.Stderr, "  %s (%s)\n", req.Spec, strings.Join(req.Sources, ", "))
+				}
+			}
+			if missing := missingBinaries(bins); len(missing) > 0 {
+				fmt.Fprintln(os.Stderr, "⚠️ Missing system binaries:")
+				for _, bin := range missing {
+					fmt.Fprintf(os.Stderr, "  %s (%s)\n", bin, strings.Join(bins[bin], ", "))
+				}
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.Flags().StringVarP(&dbName, "database", "d", "", "Only include modules installed in this database")
+	cmd.Flags().StringVarP(&outFile, "file", "f", "", "Write the requirements to this file instead of stdout")
+	cmd.Flags().BoolVar(&noFiles, "no-requirements-files", false, "Ignore the requirements.txt files of the addon repositories")
+	return cmd
+}
+
+// writeRequirements writes the merged requirements with the modules or
+// files that asked for each
+func writeRequirements(w io.Writer, reqs *addons.RequirementSet) error {
+	var sb strings.Builder
+	sb.WriteString("# Generated by ocli deps python\n")
+	for _, req := range reqs.Requirements() {
+		fmt.Fprintf(&sb, "%s  # %s\n", req.Spec, strings.Join(req.Sources, ", "))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// missingBinaries returns the binaries that are not found in PATH
+func missingBinaries(bins map[string][]string) []string {
+	var missing []string
+	for bin := range bins {
+		if _, err := exec.LookPath(bin); err != nil {
+			missing = append(missing, bin)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -39,6 +39,9 @@ to quickly create a Cobra application.`,
 				log.Fatal("Database name is required. Use --database or -d to specify it.")
 			}
 
+			// Odoo terminates the sessions of the database before dropping it
+			showConnections(cmd.Context(), configPath, dbName)
+
 			// Execute odoo-bin db drop command
 			cmdExec := exec.Command(odooBin, "db", "-c", configPath, "drop", dbName)
 
@@ -0,0 +1,386 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mjavint/ocli/pkg/config"
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/filestore"
+	"github.com/mjavint/ocli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// NewFilestoreCmd represents the filestore command
+func NewFilestoreCmd() *cobra.Command {
+	opts := &dbOptions{}
+	cmd := &cobra.Command{
+		Use:   "filestore",
+		Short: "Check and clean the filestore of a database",
+		Long: `Cross-check the filestore of a database (<data_dir>/filestore/<db>)
+with its attachments (ir_attachment), clean the files no attachment uses
+anymore and move the attachments between the database and the filestore.`,
+	}
+	cmd.PersistentFlags().StringVarP(&opts.configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.PersistentFlags().StringVarP(&opts.dbName, "database", "d", "", "Database name")
+
+	cmd.AddCommand(
+		newFilestoreCheckCmd(opts),
+		newFilestoreGcCmd(opts),
+		newFilestoreMigrateCmd(opts),
+	)
+	return cmd
+}
+
+func newFilestoreCheckCmd(opts *dbOptions) *cobra.Command {
+	var (
+		noVerify bool
+		summary  bool
+		format   string
+	)
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Report missing, corrupted and orphaned filestore files",
+		Long: `Report the attachments whose file is missing, the files whose SHA-1
+does not match the checksum of their attachments, and the orphaned files
+no attachment refers to, with their total size.
+
+Exits with status 1 when files are missing or corrupted; orphans are only
+reported, use 'ocli filestore gc' to clean them.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+			dir := filestoreDir(opts.configPath, opts.dbName)
+
+			attachments, err := db.ListStoredAttachments(cmd.Context(), conn)
+			if err != nil {
+				log.Fatal(err)
+			}
+			files, err := filestore.Scan(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+			report, err := filestore.Check(dir, files, attachments, !noVerify)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if !summary {
+				table := output.NewTable("Problem", "File", "Attachment", "Size", "Detail")
+				for _, a := range report.Missing {
+					table.AddRow("missing", a.StoreFname, attachmentLabel(a), db.FormatBytes(a.FileSize), "")
+				}
+				for _, m := range report.Mismatched {
+					table.AddRow("checksum", m.Attachment.StoreFname, attachmentLabel(m.Attachment), db.FormatBytes(m.Attachment.FileSize),
+						fmt.Sprintf("expected %s, got %s", m.Attachment.Checksum, m.Actual))
+				}
+				for _, f := range report.Orphans {
+					table.AddRow("orphan", f.Fname, "-", db.FormatBytes(f.Size), "modified "+f.ModTime.Format(time.DateTime))
+				}
+				if len(table.Rows) > 0 {
+					if err := output.Render(os.Stdout, format, table); err != nil {
+						log.Fatal(err)
+					}
+				}
+			}
+
+			fmt.Fprintf(os.Stderr, "📦 %s: %d file(s), %d attachment(s) in the filestore\n", dir, report.Files, len(attachments))
+			fmt.Fprintf(os.Stderr, "  missing:  %d\n", len(report.Missing))
+			if noVerify {
+				fmt.Fprintf(os.Stderr, "  checksum: not verified\n")
+			} else {
+				fmt.Fprintf(os.Stderr, "  checksum: %d mismatch(es)\n", len(report.Mismatched))
+			}
+			fmt.Fprintf(os.Stderr, "  orphans:  %d (%s)\n", len(report.Orphans), db.FormatBytes(report.OrphanSize()))
+			if !report.OK() {
+				fmt.Fprintln(os.Stderr, "🔴 Filestore is inconsistent")
+				os.Exit(1)
+			}
+			fmt.Fprintln(os.Stderr, "✅ Every attachment has its file")
+		},
+	}
+	cmd.Flags().BoolVar(&noVerify, "no-verify", false, "Do not hash the files to compare them with their checksum")
+	cmd.Flags().BoolVar(&summary, "summary", false, "Only print the counts")
+	output.AddFormatFlag(cmd, &format)
+	return cmd
+}
+
+func newFilestoreGcCmd(opts *dbOptions) *cobra.Command {
+	var (
+		grace         time.Duration
+		quarantine    bool
+		quarantineDir string
+		checklistOnly bool
+		dryRun        bool
+		yes           bool
+	)
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove or quarantine the orphaned filestore files",
+		Long: `Remove the files that no attachment refers to, as Odoo's own filestore
+garbage collection does: every attachment counts, whatever its res_field,
+and ir_attachment is locked in SHARE mode while files are removed so none
+can be created meanwhile. Their checklist entries are dropped too.
+
+Only files older than --grace are collected, which protects the files of
+transactions still in progress. With --checklist-only, only the files
+Odoo itself marked as garbage candidates are considered.
+
+With --quarantine the files are moved to
+<data_dir>/quarantine/<db>/<timestamp>, keeping their relative path, so
+they can be restored by moving them back.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+			dir := filestoreDir(opts.configPath, opts.dbName)
+
+			collect := func(attachments []db.StoredAttachment) ([]filestore.File, error) {
+				files, err := filestore.Scan(dir)
+				if err != nil {
+					return nil, err
+				}
+				report, err := filestore.Check(dir, files, attachments, false)
+				if err != nil {
+					return nil, err
+				}
+				cutoff := time.Now().Add(-grace)
+				var candidates []filestore.File
+				for _, f := range report.Orphans {
+					if f.ModTime.After(cutoff) || (checklistOnly && !filestore.InChecklist(dir, f.Fname)) {
+						continue
+					}
+					candidates = append(candidates, f)
+				}
+				return candidates, nil
+			}
+
+			attachments, err := db.ListStoredAttachments(ctx, conn)
+			if err != nil {
+				log.Fatal(err)
+			}
+			candidates, err := collect(attachments)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if len(candidates) == 0 {
+				fmt.Printf("✅ No orphaned files older than %s in %s\n", grace, dir)
+				return
+			}
+			var size int64
+			for _, f := range candidates {
+				size += f.Size
+			}
+			fmt.Printf("🗑️  %d orphaned file(s), %s, in %s\n", len(candidates), db.FormatBytes(size), dir)
+			if dryRun {
+				for _, f := range candidates {
+					fmt.Printf("  %s  %s  %s\n", f.Fname, db.FormatBytes(f.Size), f.ModTime.Format(time.DateTime))
+				}
+				return
+			}
+
+			verb, dest := "Delete", ""
+			if quarantine || quarantineDir != "" {
+				if quarantineDir == "" {
+					quarantineDir = defaultQuarantineDir(opts.configPath)
+				}
+				dest = filepath.Join(quarantineDir, opts.dbName, time.Now().Format("20060102-150405"))
+				verb = "Move to " + dest
+			}
+			if !confirm(fmt.Sprintf("%s %d file(s)?", verb, len(candidates)), yes) {
+				fmt.Println("Aborted, nothing changed.")
+				return
+			}
+
+			// Look again with the attachments locked, files may have been
+			// referenced since
+			var done int
+			var freed int64
+			err = db.WithAttachmentsLocked(ctx, conn, func(attachments []db.StoredAttachment) error {
+				still, err := collect(attachments)
+				if err != nil {
+					return err
+				}
+				confirmed := make(map[string]bool, len(candidates))
+				for _, f := range candidates {
+					confirmed[f.Fname] = true
+				}
+				for _, f := range still {
+					if !confirmed[f.Fname] {
+						continue
+					}
+					if dest != "" {
+						err = filestore.Quarantine(dir, dest, f.Fname)
+					} else {
+						err = filestore.Remove(dir, f.Fname)
+					}
+					if err != nil {
+						return fmt.Errorf("failed to collect %s: %w", f.Fname, err)
+					}
+					done++
+					freed += f.Size
+				}
+				return nil
+			})
+			if err != nil {
+				log.Fatalf("Error after collecting %d file(s): %v", done, err)
+			}
+			fmt.Printf("✅ Collected %d file(s), %s freed\n", done, db.FormatBytes(freed))
+		},
+	}
+	cmd.Flags().DurationVar(&grace, "grace", 24*time.Hour, "Only collect files not modified for this long")
+	cmd.Flags().BoolVar(&quarantine, "quarantine", false, "Move the files to the quarantine directory instead of deleting them")
+	cmd.Flags().StringVar(&quarantineDir, "quarantine-dir", "", "Quarantine directory (default: <data_dir>/quarantine), implies --quarantine")
+	cmd.Flags().BoolVar(&checklistOnly, "checklist-only", false, "Only collect files Odoo marked as garbage candidates")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only list the files that would be collected")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not ask for confirmation")
+	return cmd
+}
+
+func newFilestoreMigrateCmd(opts *dbOptions) *cobra.Command {
+	var (
+		to    string
+		batch int
+	)
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Move the attachments between the database and the filestore",
+		Long: `Move the content of every attachment to the filestore (--to file) or
+into the database (--to db), and set the ir_attachment.location parameter
+so new attachments follow.
+
+Attachments are moved in batches, each committed on its own, so an
+interrupted migration resumes where it stopped when run again. Moving to
+the database is useful to share a database without its filestore; moving
+to the filestore shrinks the database and its dumps.
+
+Files left unused by --to db are only marked for Odoo's garbage
+collection; remove them with 'ocli filestore gc --checklist-only'.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if to != "file" && to != "db" {
+				log.Fatalf("Invalid --to value %q, use file or db", to)
+			}
+			if batch < 1 {
+				log.Fatal("--batch must be at least 1")
+			}
+			ctx := cmd.Context()
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+			dir := filestoreDir(opts.configPath, opts.dbName)
+
+			// New attachments must already go to the target while the
+			// existing ones are moved
+			if err := db.SetConfigParameter(ctx, conn, "ir_attachment.location", to); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("✅ ir_attachment.location = %s\n", to)
+
+			inDB, inFile, err := db.CountAttachmentsByStorage(ctx, conn)
+			if err != nil {
+				log.Fatal(err)
+			}
+			total := inDB
+			if to == "db" {
+				total = inFile
+			}
+			if total == 0 {
+				fmt.Printf("✅ Every attachment of %s is already stored in %s\n", opts.dbName, to)
+				return
+			}
+			fmt.Printf("📦 Moving %d attachment(s) to %s (filestore %s)\n", total, to, dir)
+
+			start := time.Now()
+			var moved, skipped int64
+			var size int64
+			var lastID int64
+			progress := func() {
+				fmt.Printf("  %d/%d moved, %s (%s)\n", moved, total, db.FormatBytes(size), time.Since(start).Round(time.Second))
+			}
+			for {
+				if to == "file" {
+					contents, err := db.ListDBAttachments(ctx, conn, lastID, batch)
+					if err != nil {
+						log.Fatal(err)
+					}
+					if len(contents) == 0 {
+						break
+					}
+					moves := make([]db.FileMove, 0, len(contents))
+					for _, c := range contents {
+						fname, checksum, err := filestore.Write(dir, c.Datas)
+						if err != nil {
+							log.Fatalf("Error writing attachment %d: %v", c.ID, err)
+						}
+						moves = append(moves, db.FileMove{ID: c.ID, StoreFname: fname, Checksum: checksum})
+						size += int64(len(c.Datas))
+					}
+					if err := db.MoveAttachmentsToFile(ctx, conn, moves); err != nil {
+						log.Fatal(err)
+					}
+					moved += int64(len(moves))
+					lastID = contents[len(contents)-1].ID
+				} else {
+					attachments, err := db.ListFileAttachments(ctx, conn, lastID, batch)
+					if err != nil {
+						log.Fatal(err)
+					}
+					if len(attachments) == 0 {
+						break
+					}
+					contents := make([]db.AttachmentContent, 0, len(attachments))
+					var fnames []string
+					for _, a := range attachments {
+						data, err := os.ReadFile(filestore.Path(dir, a.StoreFname))
+						if err != nil {
+							fmt.Printf("⚠️ Attachment %s left in the filestore: %v\n", attachmentLabel(a), err)
+							skipped++
+							continue
+						}
+						contents = append(contents, db.AttachmentContent{ID: a.ID, Datas: data})
+						fnames = append(fnames, a.StoreFname)
+						size += int64(len(data))
+					}
+					if err := db.MoveAttachmentsToDB(ctx, conn, contents); err != nil {
+						log.Fatal(err)
+					}
+					for _, fname := range fnames {
+						filestore.MarkForGC(dir, fname)
+					}
+					moved += int64(len(contents))
+					lastID = attachments[len(attachments)-1].ID
+				}
+				progress()
+			}
+
+			fmt.Printf("✅ Moved %d attachment(s), %s, to %s in %s\n", moved, db.FormatBytes(size), to, time.Since(start).Round(time.Second))
+			if skipped > 0 {
+				fmt.Printf("⚠️ %d attachment(s) could not be read, see 'ocli filestore check'\n", skipped)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&to, "to", "", "Where to store the attachments: file or db")
+	cmd.Flags().IntVar(&batch, "batch", 100, "Number of attachments moved per transaction")
+	return cmd
+}
+
+// attachmentLabel renders an attachment as "#id name (model)"
+func attachmentLabel(a db.StoredAttachment) string {
+	label := fmt.Sprintf("#%d %s", a.ID, a.Name)
+	if a.ResModel != "" {
+		label += " (" + a.ResModel + ")"
+	}
+	return label
+}
+
+// defaultQuarantineDir is next to the filestores, where Odoo does not look
+func defaultQuarantineDir(configPath string) string {
+	if configPath == "" {
+		configPath = config.AppConfig.Odoo.ConfigFile
+	}
+	options, _ := config.ReadOdooConf(configPath)
+	return filepath.Join(config.OdooDataDir(options), "quarantine")
+}
@@ -0,0 +1,302 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mjavint/ocli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// idePrefix names the launch configurations and tasks managed by ocli, so
+// they can be updated without touching the user's own entries
+const idePrefix = "ocli: "
+
+// NewIdeCmd represents the ide command
+func NewIdeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ide",
+		Short: "Generate editor configuration for the project",
+	}
+	cmd.AddCommand(newIdeVscodeCmd())
+	return cmd
+}
+
+func newIdeVscodeCmd() *cobra.Command {
+	var (
+		odooBin    string
+		configPath string
+		dbName     string
+		dir        string
+		debugPort  int
+	)
+	cmd := &cobra.Command{
+		Use:   "vscode",
+		Short: "Generate or update the .vscode launch, settings and tasks files",
+		Long: `Generate the VS Code configuration of the project in .vscode:
+
+  launch.json    launch odoo-bin under the debugger, and attach to an
+                 instance started with ocli start --debug
+  settings.json  python.analysis.extraPaths with Odoo and the addons paths
+  tasks.json     tasks for common ocli commands
+
+Existing files are merged: only the entries named "` + idePrefix + `..." are
+replaced, and other launch configurations, tasks and settings are kept.
+Comments in existing files are not preserved.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if odooBin == "" {
+				odooBin = config.AppConfig.Odoo.OdooBin
+			}
+			if configPath == "" {
+				configPath = config.AppConfig.Odoo.ConfigFile
+			}
+			vscodeDir := filepath.Join(dir, ".vscode")
+			if err := os.MkdirAll(vscodeDir, 0755); err != nil {
+				log.Fatalf("failed to create %s: %v", vscodeDir, err)
+			}
+			addonsPaths := resolveAddonsPaths(configPath)
+
+			launchArgs := []interface{}{"-c", configPath, "--dev=xml"}
+			if len(addonsPaths) > 0 {
+				launchArgs = append(launchArgs, "--addons-path="+strings.Join(addonsPaths, ","))
+			}
+			if dbName != "" {
+				launchArgs = append(launchArgs, "-d", dbName)
+			}
+			launch := []map[string]interface{}{
+				{
+					"name":       idePrefix + "Odoo",
+					"type":       "debugpy",
+					"request":    "launch",
+					"program":    odooBin,
+					"args":       launchArgs,
+					"console":    "integratedTerminal",
+					"justMyCode": false,
+				},
+				{
+					"name":    idePrefix + "Attach",
+					"type":    "debugpy",
+					"request": "attach",
+					"connect": map[string]interface{}{
+						"host": "127.0.0.1",
+						"port": debugPort,
+					},
+					"justMyCode": false,
+				},
+			}
+			if err := mergeVscodeList(filepath.Join(vscodeDir, "launch.json"), "0.2.0", "configurations", "name", launch); err != nil {
+				log.Fatal(err)
+			}
+
+			extraPaths := []string{filepath.Dir(odooBin)}
+			extraPaths = append(extraPaths, addonsPaths...)
+			if err := mergeVscodeSettings(filepath.Join(vscodeDir, "settings.json"), extraPaths); err != nil {
+				log.Fatal(err)
+			}
+
+			dbArg := ""
+			if dbName != "" {
+				dbArg = " -d " + dbName
+			}
+			tasks := []map[string]interface{}{
+				vscodeTask("start", "ocli start"+dbArg),
+				vscodeTask("start (debug)", fmt.Sprintf("ocli start%s --debug --debug-port %d", dbArg, debugPort)),
+				vscodeTask("upgrade changed modules", "ocli upgrade"+dbArg+" --changed"),
+				vscodeTask("test module", "ocli test -m ${input:ocliModule}"),
+				vscodeTask("logs (errors)", "ocli logs -f --level ERROR"),
+				vscodeTask("update addons path", "ocli addon"),
+			}
+			if err := mergeVscodeList(filepath.Join(vscodeDir, "tasks.json"), "2.0.0", "tasks", "label", tasks); err != nil {
+				log.Fatal(err)
+			}
+			if err := mergeVscodeInputs(filepath.Join(vscodeDir, "tasks.json")); err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("✅ Updated launch.json, settings.json and tasks.json in %s\n", vscodeDir)
+		},
+	}
+	cmd.Flags().StringVarP(&odooBin, "bin", "b", "", "Path to the Odoo binary")
+	cmd.Flags().StringVarP(&configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.Flags().StringVarP(&dbName, "database", "d", "", "Database used by the launch configuration and tasks")
+	cmd.Flags().StringVar(&dir, "dir", ".", "Project directory where .vscode is written")
+	cmd.Flags().IntVar(&debugPort, "debug-port", defaultDebugPort, "Port of the debugpy attach configuration")
+	return cmd
+}
+
+func vscodeTask(label, command string) map[string]interface{} {
+	return map[string]interface{}{
+		"label":          idePrefix + label,
+		"type":           "shell",
+		"command":        command,
+		"problemMatcher": []interface{}{},
+	}
+}
+
+// mergeVscodeList replaces the entries of listKey in a VS Code JSON file
+// whose nameKey matches one of ours, appending the others
+func mergeVscodeList(path, version, listKey, nameKey string, ours []map[string]interface{}) error {
+	doc, err := readVscodeJSON(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := doc["version"]; !ok {
+		doc["version"] = version
+	}
+	existing, _ := doc[listKey].([]interface{})
+
+	byName := make(map[string]map[string]interface{}, len(ours))
+	for _, entry := range ours {
+		byName[entry[nameKey].(string)] = entry
+	}
+	merged := make([]interface{}, 0, len(existing)+len(ours))
+	for _, item := range existing {
+		if entry, ok := item.(map[string]interface{}); ok {
+			name, _ := entry[nameKey].(string)
+			if replacement, ok := byName[name]; ok {
+				merged = append(merged, replacement)
+				delete(byName, name)
+				continue
+			}
+		}
+		merged = append(merged, item)
+	}
+	for _, entry := range ours {
+		if _, pending := byName[entry[nameKey].(string)]; pending {
+			merged = append(merged, entry)
+		}
+	}
+	doc[listKey] = merged
+	return writeVscodeJSON(path, doc)
+}
+
+// mergeVscodeInputs adds the module prompt used by the test task
+func mergeVscodeInputs(path string) error {
+	input := map[string]interface{}{
+		"id":          "ocliModule",
+		"type":        "promptString",
+		"description": "Module to test",
+	}
+	doc, err := readVscodeJSON(path)
+	if err != nil {
+		return err
+	}
+	inputs, _ := doc["inputs"].([]interface{})
+	for i, item := range inputs {
+		if entry, ok := item.(map[string]interface{}); ok && entry["id"] == input["id"] {
+			inputs[i] = input
+			return writeVscodeJSON(path, doc)
+		}
+	}
+	doc["inputs"] = append(inputs, input)
+	return writeVscodeJSON(path, doc)
+}
+
+// mergeVscodeSettings adds paths to python.analysis.extraPaths, keeping
+// the paths already listed
+func mergeVscodeSettings(path string, paths []string) error {
+	doc, err := readVscodeJSON(path)
+	if err != nil {
+		return err
+	}
+	const key = "python.analysis.extraPaths"
+	existing, _ := doc[key].([]interface{})
+	seen := make(map[string]bool)
+	for _, item := range existing {
+		if s, ok := item.(string); ok {
+			seen[s] = true
+		}
+	}
+	for _, p := range paths {
+		if p != "" && !seen[p] {
+			seen[p] = true
+			existing = append(existing, p)
+		}
+	}
+	doc[key] = existing
+	return writeVscodeJSON(path, doc)
+}
+
+// readVscodeJSON reads a VS Code JSON file, which may contain comments and
+// trailing commas. A missing file reads as an empty object.
+func readVscodeJSON(path string) (map[string]interface{}, error) {
+	doc := make(map[string]interface{})
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return doc, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(strings.TrimSpace(string(content))) == 0 {
+		return doc, nil
+	}
+	if err := json.Unmarshal(stripJSONC(content), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+func writeVscodeJSON(path string, doc map[string]interface{}) error {
+	content, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", path, err)
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// stripJSONC removes // and /* */ comments and trailing commas outside of
+// strings, turning JSON with comments into plain JSON
+func stripJSONC(src []byte) []byte {
+	out := make([]byte, 0, len(src))
+	inString := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(src) {
+				i++
+				out = append(out, src[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			if i < len(src) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			i += 2
+			for i+1 < len(src) && !(src[i] == '*' && src[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == '}' || c == ']':
+			// Drop a trailing comma before the closing bracket
+			j := len(out) - 1
+			for j >= 0 && (out[j] == ' ' || out[j] == '\t' || out[j] == '\n' || out[j] == '\r') {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -1,49 +1,295 @@
 package commands
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/mjavint/ocli/pkg/addons"
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/odoo"
 	"github.com/spf13/cobra"
 )
 
+// projectSettings are the values written to ocli.yml and odoo.conf
+type projectSettings struct {
+	confPath   string
+	odooBin    string
+	roots      []string
+	addonsPath []string
+	dataDir    string
+	dumpPath   string
+	db         *db.PGConfig
+	adminPass  string
+}
+
 // initCmd represents the init command
 func NewInitCmd() *cobra.Command {
+	var (
+		confPath    string
+		skipConf    bool
+		odooBin     string
+		roots       []string
+		dataDir     string
+		dbHost      string
+		dbPort      int
+		dbUser      string
+		dbPassword  string
+		interactive bool
+		force       bool
+	)
 	cmd := &cobra.Command{
 		Use:   "init",
-		Short: "A brief description of your command",
-		Long: `Initialize a new ocli configuration file (ocli.yml) in the current directory.
+		Short: "Initialize ocli.yml and odoo.conf for a new project",
+		Long: `Initialize a new ocli configuration file (ocli.yml) in the current directory,
+together with an odoo.conf for development.
+
+Values not given as flags are detected:
+  odoo_bin      Odoo checkouts in the current directory and usual locations
+  addons_path   modules discovered below the current directory and the checkout
+  db_*          PGHOST, PGPORT, PGUSER, PGPASSWORD and the pgpass file; the
+                password is left False when none is known for the user
+  admin_passwd  randomly generated
+
+With --interactive every value is asked for, using the detected one as
+default. Existing files are never overwritten unless --force is given;
+use --skip-conf to keep an existing odoo.conf.
 
-		This command creates a default configuration file that you can customize
-		according to your project needs.`,
+Example:
+  ocli init
+  ocli init -i
+  ocli init --conf /workspace/odoo.conf --skip-conf`,
 		Run: func(cmd *cobra.Command, args []string) {
 			configFile := "ocli.yml"
+			if err := refuseOverwrite(configFile, force); err != nil {
+				log.Fatal(err)
+			}
+			if !skipConf {
+				if err := refuseOverwrite(confPath, force); err != nil {
+					log.Fatal(err)
+				}
+			}
 
-			// Check if config file already exists
-			if _, err := os.Stat(configFile); err == nil {
-				log.Fatalf("Config file %s already exists", configFile)
-			}
-
-			// Default configuration content
-			defaultConfig := `odoo:
-  config_file: /workspace/odoo.conf
-  odoo_bin: /workspace/odoo/odoo-bin
-  addons:
-    - /workspace/odoo/addons
-    - /workspace/enterprise
-    - /workspace/custom-addons
-
-db:
-  dump_path: /workspace/dbs
-  dump_format: zip`
-			// Write config file
-			if err := os.WriteFile(configFile, []byte(defaultConfig), 0644); err != nil {
-				log.Fatalf("failed to create config file: %v", err)
+			cwd, err := os.Getwd()
+			if err != nil {
+				log.Fatalf("Error obteniendo directorio actual: %v", err)
+			}
+			s := &projectSettings{db: db.DetectPGConfig()}
+			if s.db.User == "postgres" && os.Getenv("PGUSER") == "" {
+				// Odoo refuses to run as the postgres superuser; the
+				// detected password was the one of postgres
+				s.db.User = "odoo"
+				s.db.Password = db.PgpassPassword(s.db)
+			}
+			detected := *s.db
+
+			s.confPath = absPath(confPath)
+			s.odooBin = odooBin
+			if s.odooBin == "" {
+				s.odooBin = detectOdooBin(cwd)
+			}
+			if interactive {
+				s.odooBin = ask("Path to odoo-bin", s.odooBin)
+			}
+			if s.odooBin != "" {
+				s.odooBin = absPath(s.odooBin)
+			}
+
+			s.roots = roots
+			if len(s.roots) == 0 {
+				s.roots = defaultAddonRoots(cwd, s.odooBin)
 			}
+			if interactive {
+				s.roots = addons.SplitPaths(ask("Directories to scan for addons (comma separated)", strings.Join(s.roots, ",")))
+			}
+			if !skipConf {
+				discovery, err := addons.Discover(s.roots, addons.DefaultMaxDepth)
+				if err != nil {
+					log.Fatalf("failed to discover addons: %v", err)
+				}
+				printDiscovery(discovery)
+				s.addonsPath = discovery.Paths
+			}
+
+			s.dataDir = dataDir
+			if s.dataDir == "" {
+				s.dataDir = filepath.Join(cwd, "data")
+			}
+			s.dumpPath = filepath.Join(cwd, "dbs")
+			if dbHost != "" {
+				s.db.Host = dbHost
+			}
+			if dbPort != 0 {
+				s.db.Port = dbPort
+			}
+			if dbUser != "" {
+				s.db.User = dbUser
+			}
+			if interactive && !skipConf {
+				s.dataDir = ask("Data directory (filestore, sessions)", s.dataDir)
+				s.db.Host = ask("PostgreSQL host", s.db.Host)
+				if port, err := strconv.Atoi(ask("PostgreSQL port", strconv.Itoa(s.db.Port))); err == nil {
+					s.db.Port = port
+				}
+				s.db.User = ask("PostgreSQL user", s.db.User)
+			}
+			// The detected password only goes with the detected connection
+			if s.db.Host != detected.Host || s.db.Port != detected.Port || s.db.User != detected.User {
+				s.db.Password = db.PgpassPassword(s.db)
+			}
+			if dbPassword != "" {
+				s.db.Password = dbPassword
+			}
+			if interactive && !skipConf {
+				s.db.Password = ask("PostgreSQL password", s.db.Password)
+			}
+			s.dataDir = absPath(s.dataDir)
 
+			if !skipConf {
+				if s.adminPass, err = randomPassword(); err != nil {
+					log.Fatal(err)
+				}
+				if err := os.WriteFile(s.confPath, []byte(s.odooConf()), 0600); err != nil {
+					log.Fatalf("failed to create %s: %v", s.confPath, err)
+				}
+				fmt.Printf("Successfully created %s\n", s.confPath)
+			}
+			if err := os.WriteFile(configFile, []byte(s.ocliYml()), 0644); err != nil {
+				log.Fatalf("failed to create config file: %v", err)
+			}
 			fmt.Printf("Successfully created %s\n", configFile)
+			if s.odooBin == "" {
+				fmt.Println("⚠️ No Odoo checkout found, set odoo.odoo_bin in ocli.yml")
+			}
 		},
 	}
+	cmd.Flags().StringVar(&confPath, "conf", "odoo.conf", "odoo.conf to generate and reference from ocli.yml")
+	cmd.Flags().BoolVar(&skipConf, "skip-conf", false, "Only write ocli.yml, referencing an existing odoo.conf")
+	cmd.Flags().StringVarP(&odooBin, "bin", "b", "", "Path to odoo-bin (default: detected)")
+	cmd.Flags().StringSliceVarP(&roots, "path", "p", nil, "Directories to scan for addons (default: current directory and the Odoo checkout)")
+	cmd.Flags().StringVar(&dataDir, "data-dir", "", "Odoo data directory (default: ./data)")
+	cmd.Flags().StringVar(&dbHost, "db-host", "", "PostgreSQL host (default: detected)")
+	cmd.Flags().IntVar(&dbPort, "db-port", 0, "PostgreSQL port (default: detected)")
+	cmd.Flags().StringVar(&dbUser, "db-user", "", "PostgreSQL user (default: detected)")
+	cmd.Flags().StringVar(&dbPassword, "db-password", "", "PostgreSQL password (default: detected)")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Ask for each value")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite existing files")
 	return cmd
 }
+
+// odooConf renders a development odoo.conf
+func (s *projectSettings) odooConf() string {
+	var sb strings.Builder
+	sb.WriteString("[options]\n")
+	option := func(key string, value interface{}) {
+		fmt.Fprintf(&sb, "%s = %v\n", key, value)
+	}
+	option("addons_path", strings.Join(s.addonsPath, ","))
+	option("data_dir", s.dataDir)
+	option("admin_passwd", s.adminPass)
+	sb.WriteString("\n; Database\n")
+	option("db_host", s.db.Host)
+	option("db_port", s.db.Port)
+	option("db_user", s.db.User)
+	// Without a password Odoo connects as the user would with psql, e.g.
+	// through peer authentication on the local socket
+	password := s.db.Password
+	if password == "" {
+		password = "False"
+	}
+	option("db_password", password)
+	option("db_name", "False")
+	option("list_db", "True")
+	sb.WriteString("\n; Development defaults\n")
+	option("http_port", 8069)
+	option("gevent_port", 8072)
+	option("workers", 0)
+	option("max_cron_threads", 1)
+	option("limit_time_cpu", 3600)
+	option("limit_time_real", 7200)
+	option("log_level", "info")
+	option("proxy_mode", "False")
+	return sb.String()
+}
+
+// ocliYml renders ocli.yml
+func (s *projectSettings) ocliYml() string {
+	var sb strings.Builder
+	sb.WriteString("odoo:\n")
+	fmt.Fprintf(&sb, "  config_file: %s\n", s.confPath)
+	if s.odooBin != "" {
+		fmt.Fprintf(&sb, "  odoo_bin: %s\n", s.odooBin)
+	}
+	sb.WriteString("  addons:\n")
+	for _, root := range s.roots {
+		fmt.Fprintf(&sb, "    - %s\n", absPath(root))
+	}
+	sb.WriteString("\ndb:\n")
+	fmt.Fprintf(&sb, "  dump_path: %s\n", s.dumpPath)
+	sb.WriteString("  dump_format: zip\n")
+	return sb.String()
+}
+
+// refuseOverwrite fails when path exists and force is not set
+func refuseOverwrite(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+	}
+	return nil
+}
+
+// detectOdooBin looks for an Odoo checkout near dir and in the usual
+// locations, returning its odoo-bin
+func detectOdooBin(dir string) string {
+	candidates := []string{dir, "/workspace", "/opt/odoo", "/opt"}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, "odoo"), filepath.Join(home, "src"))
+	}
+	found := odoo.FindCheckouts(candidates, 2)
+	if len(found) == 0 {
+		return ""
+	}
+	if len(found) > 1 {
+		fmt.Printf("📦 Odoo checkouts found, using the first one:\n")
+		for _, bin := range found {
+			version, _ := odoo.DetectVersion(bin)
+			fmt.Printf("  - %s (%s)\n", bin, orDash(version))
+		}
+	}
+	return found[0]
+}
+
+// defaultAddonRoots returns dir, preceded by the addons of the Odoo
+// checkout when it lives elsewhere
+func defaultAddonRoots(dir, odooBin string) []string {
+	roots := []string{dir}
+	if odooBin == "" {
+		return roots
+	}
+	core := filepath.Join(filepath.Dir(odooBin), "addons")
+	if _, err := os.Stat(core); err == nil && !underAnyRoot(core, roots) {
+		roots = append([]string{core}, roots...)
+	}
+	return roots
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// randomPassword returns a random URL-safe password for admin_passwd
+func randomPassword() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -1,14 +1,58 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/mjavint/ocli/pkg/config"
+	"github.com/mjavint/ocli/pkg/db"
 	"github.com/spf13/cobra"
 )
 
+// initCachedDB clones dbName from the template matching the requested
+// modules, demo flag and language, building the template on first use
+func initCachedDB(ctx context.Context, odooBin, configPath, dbName string, modules []string, demo bool, lang string, force, quiet bool) error {
+	pgCfg, err := loadPGConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("error resolviendo configuración: %w", err)
+	}
+	exists, err := db.DBExists(ctx, dbName, pgCfg)
+	if err != nil {
+		return err
+	}
+	if exists && !force {
+		return fmt.Errorf("database %s already exists, use --force to replace it", dbName)
+	}
+
+	if len(modules) == 0 {
+		modules = []string{"base"}
+	}
+	spec, err := newTemplateSpec(odooBin, configPath, modules, demo, lang)
+	if err != nil {
+		return err
+	}
+	template, err := ensureTemplate(ctx, odooBin, configPath, pgCfg, spec, quiet)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		if err := dropDatabaseAndFilestore(ctx, configPath, dbName, pgCfg); err != nil {
+			return err
+		}
+	}
+	start := time.Now()
+	if err := cloneTemplate(ctx, configPath, template, dbName, pgCfg); err != nil {
+		return err
+	}
+	fmt.Printf("⏱️  Cloned %s from %s in %s\n", dbName, template, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
 // initdbCmd represents the initdb command
 func NewInitDBCmd() *cobra.Command {
 	var (
@@ -22,16 +66,24 @@ func NewInitDBCmd() *cobra.Command {
 		username string
 		password string
 		country  string
+		modules  []string
+		cached   bool
+		quiet    bool
 	)
 	cmd := &cobra.Command{
 		Use:   "initdb",
-		Short: "A brief description of your command",
-		Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
+		Short: "Initialize an Odoo database",
+		Long: `Initialize an Odoo database with odoo-bin db init, optionally
+installing extra modules.
+
+With --cached, the database is cloned from a template database keyed by
+the Odoo version, module list, demo flag, language and module sources.
+The template (and its filestore) is built once and reused while the key
+does not change; manage templates with ocli templates.
 
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+Example:
+  ocli initdb -d mydb -m sale_management,stock
+  ocli initdb -d mydb -m sale_management,stock --cached --force`,
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Println("initdb called")
 			//odoo-bin db -c /etc/odoo.conf init db_name
@@ -44,6 +96,20 @@ to quickly create a Cobra application.`,
 			if dbName == "" {
 				log.Fatal("Database name is required. Use --database or -d to specify it.")
 			}
+			modules = validModuleArgs(modules)
+
+			if cached {
+				if username != "" || password != "" || country != "" {
+					log.Fatal("--username, --password and --country are not supported with --cached")
+				}
+				demo := cmd.Flags().Changed("with-demo") && withDemo
+				replace := cmd.Flags().Changed("force") && force
+				if err := initCachedDB(cmd.Context(), odooBin, configPath, dbName, modules, demo, lang, replace, quiet); err != nil {
+					log.Fatal(err)
+				}
+				fmt.Printf("Database init completed successfully: %s\n", dbName)
+				return
+			}
 
 			// Build command arguments dynamically based on optional flags
 			cmdArgs := []string{"db", "-c", configPath, "init", dbName}
@@ -83,6 +149,17 @@ to quickly create a Cobra application.`,
 				log.Fatalf("Error executing init command: %v\nOutput: %s", err, string(output))
 			}
 
+			if len(modules) > 0 {
+				installArgs := []string{
+					"-c", configPath, "-d", dbName,
+					"-i", strings.Join(modules, ","),
+					"--stop-after-init", "--logfile=",
+				}
+				if err := runOdooStep(cmd.Context(), odooBin, installArgs, "Install", quiet); err != nil {
+					log.Fatal(err)
+				}
+			}
+
 			fmt.Printf("Database init completed successfully: %s\n", dbName)
 		},
 	}
@@ -95,5 +172,8 @@ to quickly create a Cobra application.`,
 	cmd.Flags().StringVar(&username, "username", "", "Administrator username")
 	cmd.Flags().StringVar(&password, "password", "", "Administrator password")
 	cmd.Flags().StringVar(&country, "country", "", "Country code for localization")
+	cmd.Flags().StringSliceVarP(&modules, "modules", "m", nil, "Modules to install after initialization (comma separated)")
+	cmd.Flags().BoolVar(&cached, "cached", false, "Clone a cached template database, building it if needed")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Do not echo the Odoo output")
 	return cmd
 }
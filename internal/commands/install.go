@@ -0,0 +1,219 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mjavint/ocli/pkg/addons"
+	"github.com/mjavint/ocli/pkg/config"
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/odoo"
+	"github.com/spf13/cobra"
+)
+
+// uninstallScript is run in odoo-bin shell; %s is a Python list of names
+const uninstallScript = `
+modules = env['ir.module.module'].search([('name', 'in', %s), ('state', '=', 'installed')])
+modules.button_immediate_uninstall()
+env.cr.commit()
+`
+
+// NewInstallCmd represents the install command
+func NewInstallCmd() *cobra.Command {
+	var (
+		odooBin    string
+		configPath string
+		dbName     string
+		quiet      bool
+	)
+	cmd := &cobra.Command{
+		Use:   "install module [module...]",
+		Short: "Install Odoo modules in a database",
+		Long: `Install modules with odoo-bin -i ... --stop-after-init and check
+afterwards that they are installed, listing the dependencies pulled in.
+
+Example:
+  ocli install -d mydb sale_management stock`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if odooBin == "" {
+				odooBin = config.AppConfig.Odoo.OdooBin
+			}
+			if configPath == "" {
+				configPath = config.AppConfig.Odoo.ConfigFile
+			}
+			if dbName == "" {
+				log.Fatal("Database name is required. Use --database or -d to specify it.")
+			}
+			modules := validModuleArgs(args)
+
+			ctx := cmd.Context()
+			conn, err := connectOdooDB(ctx, configPath, dbName)
+			if err != nil {
+				log.Fatalf("Error connecting to %s: %v", dbName, err)
+			}
+			defer db.CloseDB(conn)
+
+			before := installedSet(ctx, conn)
+			cmdArgs := []string{
+				"-c", configPath, "-d", dbName,
+				"-i", strings.Join(modules, ","),
+				"--stop-after-init", "--logfile=",
+			}
+			if err := runOdooStep(ctx, odooBin, cmdArgs, "Install", quiet); err != nil {
+				log.Fatal(err)
+			}
+			after := installedSet(ctx, conn)
+
+			var missing []string
+			for _, name := range modules {
+				if !after[name] {
+					missing = append(missing, name)
+				}
+			}
+			if deps := setDiff(after, before, modules); len(deps) > 0 {
+				fmt.Printf("📦 Dependencies installed (%d): %s\n", len(deps), strings.Join(deps, ", "))
+			}
+			if len(missing) > 0 {
+				log.Fatalf("🔴 Modules not installed after running Odoo: %s", strings.Join(missing, ", "))
+			}
+			fmt.Printf("✅ Installed: %s\n", strings.Join(modules, ", "))
+		},
+	}
+	cmd.Flags().StringVarP(&odooBin, "bin", "b", "", "Path to the Odoo binary")
+	cmd.Flags().StringVarP(&configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.Flags().StringVarP(&dbName, "database", "d", "", "Database to install the modules in")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Do not echo the Odoo output")
+	return cmd
+}
+
+// NewUninstallCmd represents the uninstall command
+func NewUninstallCmd() *cobra.Command {
+	var (
+		odooBin    string
+		configPath string
+		dbName     string
+		quiet      bool
+	)
+	cmd := &cobra.Command{
+		Use:   "uninstall module [module...]",
+		Short: "Uninstall Odoo modules from a database",
+		Long: `Uninstall modules through odoo-bin shell (button_immediate_uninstall)
+and check afterwards that they are gone, listing the dependent modules
+that were removed with them.
+
+Example:
+  ocli uninstall -d mydb website_sale`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if odooBin == "" {
+				odooBin = config.AppConfig.Odoo.OdooBin
+			}
+			if configPath == "" {
+				configPath = config.AppConfig.Odoo.ConfigFile
+			}
+			if dbName == "" {
+				log.Fatal("Database name is required. Use --database or -d to specify it.")
+			}
+			modules := validModuleArgs(args)
+
+			ctx := cmd.Context()
+			conn, err := connectOdooDB(ctx, configPath, dbName)
+			if err != nil {
+				log.Fatalf("Error connecting to %s: %v", dbName, err)
+			}
+			defer db.CloseDB(conn)
+
+			before := installedSet(ctx, conn)
+			var notInstalled []string
+			for _, name := range modules {
+				if !before[name] {
+					notInstalled = append(notInstalled, name)
+				}
+			}
+			if len(notInstalled) > 0 {
+				log.Fatalf("Modules not installed in %s: %s", dbName, strings.Join(notInstalled, ", "))
+			}
+
+			script := fmt.Sprintf(uninstallScript, pythonList(modules))
+			shellArgs := []string{"-c", configPath, "-d", dbName, "--logfile="}
+			fmt.Printf("Executing: %s shell %v\n", odooBin, shellArgs)
+			start := time.Now()
+			result, err := odoo.RunShell(ctx, odooBin, shellArgs, script, odooEcho(quiet))
+			if err := reportOdooRun("Uninstall", start, result, err); err != nil {
+				log.Fatal(err)
+			}
+			after := installedSet(ctx, conn)
+
+			var remaining []string
+			for _, name := range modules {
+				if after[name] {
+					remaining = append(remaining, name)
+				}
+			}
+			if deps := setDiff(before, after, modules); len(deps) > 0 {
+				fmt.Printf("📦 Dependent modules removed (%d): %s\n", len(deps), strings.Join(deps, ", "))
+			}
+			if len(remaining) > 0 {
+				log.Fatalf("🔴 Modules still installed after running Odoo: %s", strings.Join(remaining, ", "))
+			}
+			fmt.Printf("✅ Uninstalled: %s\n", strings.Join(modules, ", "))
+		},
+	}
+	cmd.Flags().StringVarP(&odooBin, "bin", "b", "", "Path to the Odoo binary")
+	cmd.Flags().StringVarP(&configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.Flags().StringVarP(&dbName, "database", "d", "", "Database to uninstall the modules from")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Do not echo the Odoo output")
+	return cmd
+}
+
+// validModuleArgs checks the technical names given on the command line
+func validModuleArgs(args []string) []string {
+	for _, name := range args {
+		if !addons.ValidModuleName(name) {
+			log.Fatalf("Invalid module name: %s", name)
+		}
+	}
+	return uniqueSorted(args)
+}
+
+// installedSet returns the installed modules as a set
+func installedSet(ctx context.Context, conn *sql.DB) map[string]bool {
+	installed, err := db.GetInstalledModules(ctx, conn)
+	if err != nil {
+		log.Fatalf("Error reading installed modules: %v", err)
+	}
+	set := make(map[string]bool, len(installed))
+	for _, name := range installed {
+		set[name] = true
+	}
+	return set
+}
+
+// setDiff returns the sorted names in a but not in b, excluding skip
+func setDiff(a, b map[string]bool, skip []string) []string {
+	excluded := make(map[string]bool, len(skip))
+	for _, name := range skip {
+		excluded[name] = true
+	}
+	var diff []string
+	for name := range a {
+		if !b[name] && !excluded[name] {
+			diff = append(diff, name)
+		}
+	}
+	return uniqueSorted(diff)
+}
+
+// pythonList renders names as a Python list literal
+func pythonList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
@@ -28,17 +28,11 @@ to quickly create a Cobra application.`,
 				odooConfigFile = config.AppConfig.Odoo.ConfigFile
 			}
 
-			dbConfig, err := config.LoadOdooDBParams(odooConfigFile)
+			// Construir configuración de PostgreSQL
+			pgCfg, err := loadPGConfig(odooConfigFile)
 			if err != nil {
 				log.Fatalf("Error resolviendo configuración: %v", err)
 			}
-			// Construir configuración de PostgreSQL
-			pgCfg := &db.PGConfig{
-				Host:     dbConfig.Host,
-				Port:     dbConfig.Port,
-				User:     dbConfig.User,
-				Password: dbConfig.Password,
-			}
 			// Listar bases de datos
 			dblist, err := db.ListDatabases(cmd.Context(), pgCfg)
 			if err != nil {
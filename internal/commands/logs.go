@@ -0,0 +1,313 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mjavint/ocli/pkg/config"
+	"github.com/mjavint/ocli/pkg/odoo"
+	"github.com/spf13/cobra"
+)
+
+// NewLogsCmd represents the logs command
+func NewLogsCmd() *cobra.Command {
+	var (
+		configPath string
+		logFile    string
+		instName   string
+		follow     bool
+		since      string
+		level      string
+		lines      int
+	)
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Tail and filter the Odoo log file",
+		Long: `Show the last records of the Odoo log file, optionally filtered by
+minimum level and age, and keep following it with --follow.
+
+The log file is taken from --file, from the instance given with --instance
+or from the logfile option of odoo.conf.
+
+Example:
+  ocli logs -f --level WARNING
+  ocli logs -i v18 -f
+  ocli logs --since 10m --level ERROR
+  ocli logs summary --since 1h`,
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := resolveLogFile(configPath, logFile, instName)
+			if err != nil {
+				log.Fatal(err)
+			}
+			filter, err := buildLogFilter(level, since)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if err := tailLog(ctx, path, filter, lines, follow); err != nil {
+				log.Fatalf("Error reading log %s: %v", path, err)
+			}
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.PersistentFlags().StringVar(&logFile, "file", "", "Log file to read (default: logfile from odoo.conf)")
+	cmd.PersistentFlags().StringVarP(&instName, "instance", "i", "", "Read the log file of a running instance")
+	cmd.PersistentFlags().StringVar(&since, "since", "", "Only records newer than a duration (10m, 2h) or timestamp (2006-01-02 15:04:05)")
+	cmd.Flags().StringVarP(&level, "level", "l", "", "Minimum level to show (DEBUG, INFO, WARNING, ERROR, CRITICAL)")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep reading new records as they are written")
+	cmd.Flags().IntVarP(&lines, "lines", "n", 50, "Number of matching records to show (0 for all)")
+
+	cmd.AddCommand(newLogsSummaryCmd(&configPath, &logFile, &instName, &since))
+	return cmd
+}
+
+func newLogsSummaryCmd(configPath, logFile, instName, since *string) *cobra.Command {
+	var (
+		level string
+		top   int
+	)
+	cmd := &cobra.Command{
+		Use:   "summary",
+		Short: "Group errors and warnings from the Odoo log",
+		Long: `Group ERROR and WARNING records by logger and normalized message,
+count occurrences and show the first traceback of each group.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := resolveLogFile(*configPath, *logFile, *instName)
+			if err != nil {
+				log.Fatal(err)
+			}
+			filter, err := buildLogFilter(level, *since)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				log.Fatalf("Error opening log file: %v", err)
+			}
+			defer file.Close()
+
+			summary := odoo.NewLogSummary()
+			total := 0
+			err = odoo.ParseLog(file, func(r *odoo.LogRecord) error {
+				if filter.Match(r) {
+					summary.Add(r)
+					total++
+				}
+				return nil
+			})
+			if err != nil {
+				log.Fatalf("Error parsing log file: %v", err)
+			}
+
+			groups := summary.Groups()
+			fmt.Printf("\n%d record(s) in %d group(s) from %s\n", total, len(groups), path)
+			if top > 0 && len(groups) > top {
+				groups = groups[:top]
+			}
+			for _, g := range groups {
+				fmt.Printf("\n[%s] %dx %s: %s\n", g.Level, g.Count, g.Logger, g.Message)
+				fmt.Printf("    first: %s  last: %s\n",
+					g.First.Format(time.DateTime), g.Last.Format(time.DateTime))
+				if g.Traceback != "" {
+					fmt.Println(indent(g.Traceback, "    "))
+				}
+			}
+			fmt.Println()
+		},
+	}
+	cmd.Flags().StringVarP(&level, "level", "l", "WARNING", "Minimum level to include")
+	cmd.Flags().IntVar(&top, "top", 0, "Only show the N most relevant groups (0 for all)")
+	return cmd
+}
+
+// resolveLogFile returns the explicit log file, the one of an instance or
+// the logfile set in odoo.conf
+func resolveLogFile(configPath, logFile, instName string) (string, error) {
+	if logFile != "" {
+		return logFile, nil
+	}
+	if instName != "" {
+		inst, err := newInstanceRegistry().Load(instName)
+		if err != nil {
+			return "", err
+		}
+		if inst.LogFile == "" {
+			return "", fmt.Errorf("instance %s logs to stdout, use --file to specify a log file", instName)
+		}
+		return inst.LogFile, nil
+	}
+	if configPath == "" {
+		configPath = config.AppConfig.Odoo.ConfigFile
+	}
+	options, err := config.ReadOdooConf(configPath)
+	if err != nil {
+		return "", err
+	}
+	if path := confValue(options, "logfile"); path != "" {
+		return path, nil
+	}
+	return "", fmt.Errorf("no logfile configured in %s, use --file to specify one", configPath)
+}
+
+func buildLogFilter(level, since string) (odoo.LogFilter, error) {
+	filter := odoo.LogFilter{}
+	if level != "" {
+		if !odoo.ValidLogLevel(level) {
+			return filter, fmt.Errorf("invalid log level: %s", level)
+		}
+		filter.MinLevel = strings.ToUpper(level)
+	}
+	if since != "" {
+		t, err := parseSince(since)
+		if err != nil {
+			return filter, err
+		}
+		filter.Since = t
+	}
+	return filter, nil
+}
+
+// parseSince accepts a duration relative to now or an absolute UTC timestamp
+func parseSince(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().UTC().Add(-d), nil
+	}
+	for _, layout := range []string{time.DateTime, time.DateOnly, time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value: %s", value)
+}
+
+// tailLog prints the last matching records and optionally follows the file
+func tailLog(ctx context.Context, path string, filter odoo.LogFilter, lines int, follow bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { file.Close() }()
+
+	var tail []*odoo.LogRecord
+	keep := func(r *odoo.LogRecord) {
+		if !filter.Match(r) {
+			return
+		}
+		tail = append(tail, r)
+		if lines > 0 && len(tail) > lines {
+			tail = tail[1:]
+		}
+	}
+
+	parser := &odoo.LogParser{}
+	reader := bufio.NewReader(file)
+	var (
+		offset  int64
+		partial string
+	)
+	for {
+		line, err := reader.ReadString('\n')
+		offset += int64(len(line))
+		if errors.Is(err, io.EOF) {
+			partial = line
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if r := parser.Feed(line); r != nil {
+			keep(r)
+		}
+	}
+	if !follow {
+		if partial != "" {
+			if r := parser.Feed(partial); r != nil {
+				keep(r)
+			}
+		}
+		if r := parser.Flush(); r != nil {
+			keep(r)
+		}
+	}
+	for _, r := range tail {
+		fmt.Println(r.Raw())
+	}
+	if !follow {
+		return nil
+	}
+
+	emit := func(r *odoo.LogRecord) {
+		if r != nil && filter.Match(r) {
+			fmt.Println(r.Raw())
+		}
+	}
+	idle := 0
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		line, err := reader.ReadString('\n')
+		if err == nil {
+			offset += int64(len(line))
+			emit(parser.Feed(partial + line))
+			partial = ""
+			idle = 0
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			return err
+		}
+		offset += int64(len(line))
+		partial += line
+
+		select {
+		case <-ctx.Done():
+			emit(parser.Flush())
+			return nil
+		case <-ticker.C:
+		}
+
+		// A record is only known to be complete when the next one starts;
+		// flush it after a second without new data.
+		idle++
+		if idle == 2 {
+			emit(parser.Flush())
+		}
+
+		// Detect truncation or rotation and start again from the beginning
+		info, statErr := os.Stat(path)
+		if statErr != nil {
+			continue
+		}
+		current, _ := file.Stat()
+		if info.Size() < offset || (current != nil && !os.SameFile(info, current)) {
+			file.Close()
+			if file, err = os.Open(path); err != nil {
+				return err
+			}
+			reader = bufio.NewReader(file)
+			offset, partial = 0, ""
+		}
+	}
+}
+
+// indent prefixes every line of text with prefix
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,369 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/mail"
+	"github.com/mjavint/ocli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// defaultMailDir is where the catcher stores messages
+const defaultMailDir = ".ocli/mail"
+
+// catcherServerName is the ir_mail_server created by mail redirect
+const catcherServerName = "ocli mail catcher"
+
+// NewMailCmd represents the mail command
+func NewMailCmd() *cobra.Command {
+	opts := &dbOptions{}
+	var dir string
+	cmd := &cobra.Command{
+		Use:   "mail",
+		Short: "Catch and inspect the mail sent by Odoo",
+		Long: `Catch the mail sent by a development database instead of delivering it.
+
+Run the catcher, point the database at it and read the messages:
+  ocli mail catch
+  ocli mail redirect -d database_name
+  ocli mail list
+  ocli mail show 20240101-120000-0001
+
+Inspect the outgoing queue (mail_mail) of a database and retry or cancel
+the failed mail:
+  ocli mail queue -d database_name --state exception
+  ocli mail requeue -d database_name --failure "Connection refused"
+  ocli mail cancel -d database_name --before 168h`,
+	}
+	cmd.PersistentFlags().StringVarP(&opts.configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.PersistentFlags().StringVarP(&opts.dbName, "database", "d", "", "Database name")
+	cmd.PersistentFlags().StringVar(&dir, "dir", defaultMailDir, "Directory where caught messages are stored")
+
+	cmd.AddCommand(
+		newMailCatchCmd(&dir),
+		newMailListCmd(&dir),
+		newMailShowCmd(&dir),
+		newMailRedirectCmd(opts),
+		newMailQueueCmd(opts),
+		newMailStateCmd(opts, "requeue"),
+		newMailStateCmd(opts, "cancel"),
+	)
+	return cmd
+}
+
+func newMailCatchCmd(dir *string) *cobra.Command {
+	var addr string
+	cmd := &cobra.Command{
+		Use:   "catch",
+		Short: "Run an SMTP server that stores every message as an .eml file",
+		Long: `Run an SMTP server on localhost that accepts every message, whatever
+the sender, recipients or credentials, and stores it in --dir instead of
+delivering it. Stop it with Ctrl+C.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			server := &mail.Server{
+				Addr: addr,
+				Dir:  *dir,
+				OnMessage: func(path string) {
+					msg, err := mail.Find(*dir, path)
+					if err != nil {
+						fmt.Printf("📨 %s\n", path)
+						return
+					}
+					fmt.Printf("📨 %s  %s -> %s  %s\n", msg.ID, msg.From, msg.To, msg.Subject)
+				},
+			}
+			fmt.Printf("📬 Catching mail on %s, storing in %s (Ctrl+C to stop)\n", addr, *dir)
+			if err := server.ListenAndServe(ctx); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println("\n🛑 Mail catcher stopped")
+		},
+	}
+	cmd.Flags().StringVar(&addr, "listen", mail.DefaultAddr, "Address to listen on")
+	return cmd
+}
+
+func newMailListCmd(dir *string) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the caught messages",
+		Run: func(cmd *cobra.Command, args []string) {
+			messages, err := mail.List(*dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+			table := output.NewTable("ID", "Date", "From", "To", "Subject", "Size")
+			for _, m := range messages {
+				table.AddRow(m.ID, m.Date.Local().Format(time.DateTime), orDash(m.From), orDash(m.To), orDash(m.Subject), db.FormatBytes(m.Size))
+			}
+			if err := output.Render(os.Stdout, format, table); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	output.AddFormatFlag(cmd, &format)
+	return cmd
+}
+
+func newMailShowCmd(dir *string) *cobra.Command {
+	var raw bool
+	cmd := &cobra.Command{
+		Use:   "show id",
+		Short: "Print a caught message",
+		Long: `Print the main headers and the text body of a caught message, or the
+HTML body when it has no text part. Use --raw for the whole .eml file.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			msg, err := mail.Find(*dir, args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			if raw {
+				content, err := os.ReadFile(msg.Path)
+				if err != nil {
+					log.Fatal(err)
+				}
+				os.Stdout.Write(content)
+				return
+			}
+
+			header, body, err := mail.Body(msg.Path)
+			if err != nil {
+				log.Fatal(err)
+			}
+			printField("From", msg.From)
+			printField("To", msg.To)
+			printField("Cc", header.Get("Cc"))
+			printField("Envelope to", header.Get(mail.HeaderRcptTo))
+			printField("Reply-To", header.Get("Reply-To"))
+			printField("Date", msg.Date.Local().Format(time.DateTime))
+			printField("Subject", msg.Subject)
+			fmt.Println()
+			fmt.Println(strings.TrimRight(body, "\r\n"))
+		},
+	}
+	cmd.Flags().BoolVar(&raw, "raw", false, "Print the whole message as received")
+	return cmd
+}
+
+func newMailRedirectCmd(opts *dbOptions) *cobra.Command {
+	var addr string
+	cmd := &cobra.Command{
+		Use:   "redirect",
+		Short: "Send all the mail of a database to the catcher",
+		Long: `Configure a single outgoing mail server (ir_mail_server) pointing at
+the catcher and deactivate every other one, so no mail of the database
+can reach real recipients.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			host, portStr, err := net.SplitHostPort(addr)
+			if err != nil {
+				log.Fatalf("Invalid address %s: %v", addr, err)
+			}
+			port, err := strconv.Atoi(portStr)
+			if err != nil {
+				log.Fatalf("Invalid port in %s", addr)
+			}
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+
+			disabled, err := db.RedirectMailServers(cmd.Context(), conn, catcherServerName, host, port)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("✅ Mail of %s now goes to %s (%d other server(s) deactivated)\n", opts.dbName, addr, disabled)
+			fmt.Println("Run 'ocli mail catch' to receive it")
+		},
+	}
+	cmd.Flags().StringVar(&addr, "to", mail.DefaultAddr, "Address of the catcher")
+	return cmd
+}
+
+// mailFilterFlags are the flags selecting mail_mail records
+type mailFilterFlags struct {
+	states    []string
+	ids       []int64
+	model     string
+	failure   string
+	recipient string
+	since     string
+	before    string
+}
+
+func (f *mailFilterFlags) add(cmd *cobra.Command, states []string, stateHelp string) {
+	cmd.Flags().StringSliceVar(&f.states, "state", states, stateHelp)
+	cmd.Flags().Int64SliceVar(&f.ids, "id", nil, "Only these mail_mail ids")
+	cmd.Flags().StringVar(&f.model, "model", "", "Only mail sent from records of this model, e.g. sale.order")
+	cmd.Flags().StringVar(&f.failure, "failure", "", "Only mail whose failure reason contains this text")
+	cmd.Flags().StringVar(&f.recipient, "recipient", "", "Only mail whose recipients contain this text")
+	cmd.Flags().StringVar(&f.since, "since", "", "Only mail created after a duration ago (2h, 72h) or a UTC timestamp")
+	cmd.Flags().StringVar(&f.before, "before", "", "Only mail created before a duration ago or a UTC timestamp")
+}
+
+func (f *mailFilterFlags) filter() (db.MailFilter, error) {
+	filter := db.MailFilter{
+		States:    f.states,
+		IDs:       f.ids,
+		Model:     f.model,
+		Failure:   f.failure,
+		Recipient: f.recipient,
+	}
+	var err error
+	if f.since != "" {
+		if filter.Since, err = parseSince(f.since); err != nil {
+			return filter, err
+		}
+	}
+	if f.before != "" {
+		if filter.Before, err = parseSince(f.before); err != nil {
+			return filter, fmt.Errorf("invalid --before value: %s", f.before)
+		}
+	}
+	return filter, nil
+}
+
+func newMailQueueCmd(opts *dbOptions) *cobra.Command {
+	var (
+		filterFlags mailFilterFlags
+		limit       int
+		format      string
+	)
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "List the outgoing mail of a database (mail_mail)",
+		Long: `List the mail_mail records of a database, newest first, with their
+recipients, subject and failure reason. The number of records per state
+is printed first.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			filter, err := filterFlags.filter()
+			if err != nil {
+				log.Fatal(err)
+			}
+			ctx := cmd.Context()
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+
+			counts, err := db.CountMailByState(ctx, conn, filter)
+			if err != nil {
+				log.Fatal(err)
+			}
+			mails, err := db.ListQueuedMail(ctx, conn, filter, limit)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Fprintln(os.Stderr, mailCounts(counts))
+			table := output.NewTable("ID", "State", "Created", "Recipients", "Subject", "Model", "Failure")
+			for _, m := range mails {
+				table.AddRow(m.ID, m.State, m.CreateDate.Format(time.DateTime), orDash(m.Recipients),
+					orDash(m.Subject), orDash(m.Model), orDash(firstLine(m.FailureReason)))
+			}
+			if err := output.Render(os.Stdout, format, table); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	filterFlags.add(cmd, db.MailStates, "States to list (outgoing, exception, sent, cancel, received)")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 50, "Maximum number of records to list (0 for all)")
+	output.AddFormatFlag(cmd, &format)
+	return cmd
+}
+
+// newMailStateCmd builds requeue, which moves failed mail back to the
+// queue, and cancel, which gives up on it
+func newMailStateCmd(opts *dbOptions, use string) *cobra.Command {
+	var (
+		filterFlags mailFilterFlags
+		yes         bool
+	)
+	state, short, allowed := "outgoing", "Put failed mail back in the outgoing queue", []string{"exception", "cancel"}
+	if use == "cancel" {
+		state, short, allowed = "cancel", "Cancel failed or pending mail", []string{"exception", "outgoing"}
+	}
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long: short + `.
+
+Every mail_mail record matching the filters is changed after
+confirmation. By default only mail in exception is selected.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, s := range filterFlags.states {
+				if s != allowed[0] && s != allowed[1] {
+					log.Fatalf("Cannot %s mail in state %s, use --state with %s", use, s, strings.Join(allowed, " or "))
+				}
+			}
+			filter, err := filterFlags.filter()
+			if err != nil {
+				log.Fatal(err)
+			}
+			ctx := cmd.Context()
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+
+			counts, err := db.CountMailByState(ctx, conn, filter)
+			if err != nil {
+				log.Fatal(err)
+			}
+			var total int64
+			for _, n := range counts {
+				total += n
+			}
+			if total == 0 {
+				fmt.Printf("✅ No mail in %s matches\n", opts.dbName)
+				return
+			}
+			fmt.Printf("Matching mail: %s\n", mailCounts(counts))
+			if !confirm(fmt.Sprintf("Set %d mail to %s in %s?", total, state, opts.dbName), yes) {
+				fmt.Println("Aborted, nothing changed.")
+				return
+			}
+			changed, err := db.SetMailState(ctx, conn, filter, state)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("✅ %d mail set to %s\n", changed, state)
+			if state == "outgoing" {
+				fmt.Println("They will be sent by the next run of the mail queue cron")
+			}
+		},
+	}
+	filterFlags.add(cmd, []string{"exception"}, "States to select ("+strings.Join(allowed, ", ")+")")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not ask for confirmation")
+	return cmd
+}
+
+// mailCounts renders the number of mail per state, e.g. "exception: 3, sent: 10"
+func mailCounts(counts map[string]int64) string {
+	if len(counts) == 0 {
+		return "no mail"
+	}
+	states := make([]string, 0, len(counts))
+	for state := range counts {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+	parts := make([]string, len(states))
+	for i, state := range states {
+		parts[i] = fmt.Sprintf("%s: %d", state, counts[state])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,461 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mjavint/ocli/pkg/addons"
+	"github.com/mjavint/ocli/pkg/config"
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/odoo"
+	"github.com/mjavint/ocli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// NewModulesCmd represents the modules command
+func NewModulesCmd() *cobra.Command {
+	opts := &dbOptions{}
+	cmd := &cobra.Command{
+		Use:   "modules",
+		Short: "Inspect the modules in the addons paths",
+		Long: `Inspect the modules found in the addons_path of odoo.conf by parsing
+their __manifest__.py. With --database, each module is matched with its
+installation state in that database.`,
+	}
+	cmd.PersistentFlags().StringVarP(&opts.configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.PersistentFlags().StringVarP(&opts.dbName, "database", "d", "", "Database to read installed modules from")
+
+	cmd.AddCommand(newModulesListCmd(opts))
+	cmd.AddCommand(newModulesShowCmd(opts))
+	cmd.AddCommand(newModulesGraphCmd(opts))
+	cmd.AddCommand(newModulesRdepsCmd(opts))
+	cmd.AddCommand(newModulesDriftCmd(opts))
+	cmd.AddCommand(newModulesFixStatesCmd(opts))
+	return cmd
+}
+
+func newModulesListCmd(opts *dbOptions) *cobra.Command {
+	var (
+		format    string
+		installed bool
+		all       bool
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List local modules with version, path and dependencies",
+		Run: func(cmd *cobra.Command, args []string) {
+			if installed && opts.dbName == "" {
+				log.Fatal("--installed requires --database or -d.")
+			}
+			modules := loadLocalModules(opts.configPath)
+			installedMods := opts.installedModules(cmd)
+
+			headers := []string{"Name", "Version", "Depends", "Path"}
+			if installedMods != nil {
+				headers = []string{"Name", "Version", "Installed", "Depends", "Path"}
+			}
+			table := output.NewTable(headers...)
+			for _, mod := range modules {
+				if mod.Err != nil {
+					fmt.Fprintf(os.Stderr, "⚠️ %s: %v\n", mod.Name, mod.Err)
+					continue
+				}
+				if !all && !mod.Manifest.Installable {
+					continue
+				}
+				if installed && !installedMods[mod.Name] {
+					continue
+				}
+				depends := strings.Join(mod.Manifest.Depends, ",")
+				if installedMods != nil {
+					table.AddRow(mod.Name, orDash(mod.Manifest.Version), yesNo(installedMods[mod.Name]), orDash(depends), mod.Dir)
+				} else {
+					table.AddRow(mod.Name, orDash(mod.Manifest.Version), orDash(depends), mod.Dir)
+				}
+			}
+			if err := output.Render(os.Stdout, format, table); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	output.AddFormatFlag(cmd, &format)
+	cmd.Flags().BoolVar(&installed, "installed", false, "Only list modules installed in the database")
+	cmd.Flags().BoolVar(&all, "all", false, "Include modules that are not installable")
+	return cmd
+}
+
+func newModulesShowCmd(opts *dbOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show module",
+		Short: "Show the manifest details of a module",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			paths := resolveAddonsPaths(opts.configPath)
+			dir, ok := addons.FindModule(paths, args[0])
+			if !ok {
+				log.Fatalf("Module %s not found in addons paths", args[0])
+			}
+			m, err := addons.ReadManifest(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("%s (%s)\n\n", args[0], m.Name)
+			printField("Version", m.Version)
+			printField("Summary", m.Summary)
+			printField("Author", m.Author)
+			printField("License", m.License)
+			printField("Category", m.Category)
+			printField("Path", dir)
+			printField("Depends", strings.Join(m.Depends, ", "))
+			printField("Installable", yesNo(m.Installable))
+			printField("Auto install", yesNo(m.AutoInstall))
+			printField("Application", yesNo(m.Application))
+			kinds := make([]string, 0, len(m.ExternalDependencies))
+			for kind := range m.ExternalDependencies {
+				kinds = append(kinds, kind)
+			}
+			sort.Strings(kinds)
+			for _, kind := range kinds {
+				printField("External "+kind, strings.Join(m.ExternalDependencies[kind], ", "))
+			}
+			printField("Data files", fmt.Sprint(len(m.Data)))
+			if installedMods := opts.installedModules(cmd); installedMods != nil {
+				printField("Installed", fmt.Sprintf("%s (%s)", yesNo(installedMods[args[0]]), opts.dbName))
+			}
+		},
+	}
+	return cmd
+}
+
+func newModulesGraphCmd(opts *dbOptions) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "graph [module]",
+		Short: "Output the module dependency graph as DOT, Mermaid or JSON",
+		Long: `Output the dependency graph of the local modules. With a module name,
+only that module and its transitive dependencies are included.
+
+Dependency cycles and dependencies missing from the addons paths are
+reported on stderr, and included in the JSON output.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			graph := addons.NewGraph(loadLocalModules(opts.configPath))
+			var nodes []string
+			if len(args) == 1 {
+				if !graph.Has(args[0]) {
+					log.Fatalf("Module %s not found in addons paths", args[0])
+				}
+				nodes = graph.Closure(args[0])
+			} else {
+				nodes = graph.Names()
+			}
+
+			var err error
+			switch format {
+			case "dot":
+				err = graph.WriteDOT(os.Stdout, nodes)
+			case "mermaid":
+				err = graph.WriteMermaid(os.Stdout, nodes)
+			case "json":
+				err = graph.WriteJSON(os.Stdout, nodes)
+			default:
+				log.Fatalf("Invalid format %q. Use dot, mermaid or json.", format)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			if !reportGraphProblems(graph, nodes) {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", "dot", "Graph format: dot, mermaid or json")
+	return cmd
+}
+
+func newModulesRdepsCmd(opts *dbOptions) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "rdeps module",
+		Short: "List the modules that depend on a module, transitively",
+		Long: `List every local module that depends on the given module, directly or
+through other modules. These are the modules Odoo upgrades along with it.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			graph := addons.NewGraph(loadLocalModules(opts.configPath))
+			if !graph.Has(args[0]) {
+				log.Fatalf("Module %s not found in addons paths", args[0])
+			}
+			rdeps := graph.ReverseDeps(args[0])
+			names := make([]string, 0, len(rdeps))
+			for name := range rdeps {
+				names = append(names, name)
+			}
+			sort.Slice(names, func(i, j int) bool {
+				if rdeps[names[i]] != rdeps[names[j]] {
+					return rdeps[names[i]] < rdeps[names[j]]
+				}
+				return names[i] < names[j]
+			})
+
+			table := output.NewTable("Name", "Depth", "Depends")
+			for _, name := range names {
+				table.AddRow(name, rdeps[name], strings.Join(graph.Deps[name], ","))
+			}
+			if err := output.Render(os.Stdout, format, table); err != nil {
+				log.Fatal(err)
+			}
+			if !reportGraphProblems(graph, append(names, args[0])) {
+				os.Exit(1)
+			}
+		},
+	}
+	output.AddFormatFlag(cmd, &format)
+	return cmd
+}
+
+func newModulesDriftCmd(opts *dbOptions) *cobra.Command {
+	var (
+		format string
+		all    bool
+	)
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Compare the module versions of a database with the local sources",
+		Long: `Compare the latest_version of each module in ir_module_module with the
+version of its local __manifest__.py, and report:
+
+  outdated     installed, the local code is newer: it needs an upgrade
+  code older   installed, the local code is older than the database
+  missing      installed in the database but not found on disk
+  stuck        left in to install, to upgrade or to remove
+
+Exits with status 1 when any drift is found.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+			records, err := db.GetModules(ctx, conn)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			local := make(map[string]*addons.Module)
+			for _, mod := range loadLocalModules(opts.configPath) {
+				local[mod.Name] = mod
+			}
+			series := ""
+			for _, rec := range records {
+				if rec.Name == "base" {
+					series = odoo.SeriesOf(rec.LatestVersion)
+				}
+			}
+			if series == "" {
+				series, _ = odoo.DetectVersion(config.AppConfig.Odoo.OdooBin)
+			}
+
+			table := output.NewTable("Name", "State", "DB version", "Local version", "Status")
+			drifted := 0
+			for _, rec := range records {
+				if rec.State == "uninstalled" || rec.State == "uninstallable" {
+					continue
+				}
+				localVersion := ""
+				mod, onDisk := local[rec.Name]
+				if onDisk && mod.Manifest != nil {
+					localVersion = odoo.AdaptVersion(mod.Manifest.Version, series)
+				}
+				status := moduleDrift(rec, onDisk, localVersion)
+				if status != "ok" {
+					drifted++
+				} else if !all {
+					continue
+				}
+				table.AddRow(rec.Name, rec.State, orDash(rec.LatestVersion), orDash(localVersion), status)
+			}
+			if err := output.Render(os.Stdout, format, table); err != nil {
+				log.Fatal(err)
+			}
+			if drifted > 0 {
+				fmt.Fprintf(os.Stderr, "⚠️ %d module(s) drifted in %s\n", drifted, opts.dbName)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "✅ %s matches the local sources\n", opts.dbName)
+		},
+	}
+	output.AddFormatFlag(cmd, &format)
+	cmd.Flags().BoolVar(&all, "all", false, "Also list the modules without drift")
+	return cmd
+}
+
+func newModulesFixStatesCmd(opts *dbOptions) *cobra.Command {
+	var (
+		clearOrphans bool
+		yes          bool
+	)
+	cmd := &cobra.Command{
+		Use:   "fix-states",
+		Short: "Reset modules stuck in to install, to upgrade or to remove",
+		Long: `List the modules left in a transitional state by an interrupted install,
+upgrade or uninstall and, after confirmation, reset them to their last
+stable state: to install becomes uninstalled, to upgrade and to remove
+become installed.
+
+With --clear-orphans, ir_module_module_dependency rows pointing to modules
+unknown to ir_module_module are deleted too. All changes run in a single
+transaction.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+
+			records, err := db.GetModules(ctx, conn)
+			if err != nil {
+				log.Fatal(err)
+			}
+			table := output.NewTable("Name", "State", "Reset to")
+			for _, rec := range records {
+				if db.IsTransitionalState(rec.State) {
+					table.AddRow(rec.Name, rec.State, db.StableState(rec.State))
+				}
+			}
+			var orphans int64
+			if clearOrphans {
+				if orphans, err = db.CountOrphanDependencies(ctx, conn); err != nil {
+					log.Fatal(err)
+				}
+			}
+			if len(table.Rows) == 0 && orphans == 0 {
+				fmt.Printf("✅ No modules in transitional states in %s\n", opts.dbName)
+				return
+			}
+			if len(table.Rows) > 0 {
+				output.Render(os.Stdout, output.FormatTable, table)
+			}
+			if orphans > 0 {
+				fmt.Printf("%d orphaned ir_module_module_dependency row(s)\n", orphans)
+			}
+
+			if !confirm(fmt.Sprintf("Apply these changes to %s?", opts.dbName), yes) {
+				fmt.Println("Aborted, nothing changed.")
+				return
+			}
+			resets, cleared, err := db.ResetModuleStates(ctx, conn, clearOrphans)
+			if err != nil {
+				log.Fatalf("Error fixing module states: %v", err)
+			}
+			counts := make(map[string]int)
+			for _, r := range resets {
+				counts[r.From+" -> "+r.To]++
+			}
+			transitions := make([]string, 0, len(counts))
+			for t := range counts {
+				transitions = append(transitions, t)
+			}
+			sort.Strings(transitions)
+			for _, t := range transitions {
+				fmt.Printf("  %-28s %d\n", t, counts[t])
+			}
+			fmt.Printf("✅ Reset %d module(s)", len(resets))
+			if clearOrphans {
+				fmt.Printf(", deleted %d orphaned dependency row(s)", cleared)
+			}
+			fmt.Println()
+		},
+	}
+	cmd.Flags().BoolVar(&clearOrphans, "clear-orphans", false, "Also delete orphaned ir_module_module_dependency rows")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not ask for confirmation")
+	return cmd
+}
+
+// moduleDrift classifies a database module against its local version
+func moduleDrift(rec db.ModuleRecord, onDisk bool, localVersion string) string {
+	var issues []string
+	if !onDisk {
+		issues = append(issues, "missing")
+	}
+	if db.IsTransitionalState(rec.State) {
+		issues = append(issues, "stuck")
+	}
+	if onDisk && rec.LatestVersion != "" && localVersion != "" {
+		switch odoo.CompareVersions(localVersion, rec.LatestVersion) {
+		case 1:
+			issues = append(issues, "outdated")
+		case -1:
+			issues = append(issues, "code older")
+		}
+	}
+	if len(issues) == 0 {
+		return "ok"
+	}
+	return strings.Join(issues, ", ")
+}
+
+// reportGraphProblems prints the cycles and missing dependencies that
+// involve nodes to stderr, and reports whether there were none
+func reportGraphProblems(graph *addons.Graph, nodes []string) bool {
+	inNodes := make(map[string]bool, len(nodes))
+	for _, name := range nodes {
+		inNodes[name] = true
+	}
+	ok := true
+	for _, cycle := range graph.Cycles() {
+		if inNodes[cycle[0]] {
+			fmt.Fprintf(os.Stderr, "🔴 Dependency cycle between: %s\n", strings.Join(cycle, ", "))
+			ok = false
+		}
+	}
+	for _, name := range nodes {
+		if missing := graph.Missing[name]; len(missing) > 0 {
+			fmt.Fprintf(os.Stderr, "⚠️ %s depends on missing module(s): %s\n", name, strings.Join(missing, ", "))
+			ok = false
+		}
+	}
+	return ok
+}
+
+// installedModules returns the installed modules of --database, or nil
+// when no database was given
+func (opts *dbOptions) installedModules(cmd *cobra.Command) map[string]bool {
+	if opts.dbName == "" {
+		return nil
+	}
+	conn, err := connectOdooDB(cmd.Context(), opts.configPath, opts.dbName)
+	if err != nil {
+		log.Fatalf("Error connecting to %s: %v", opts.dbName, err)
+	}
+	defer db.CloseDB(conn)
+	return installedSet(cmd.Context(), conn)
+}
+
+// loadLocalModules reads every module of the configured addons paths and
+// the core addons of odoo-bin
+func loadLocalModules(configPath string) []*addons.Module {
+	paths := resolveAddonsPaths(configPath)
+	if core := coreAddonsPath(); core != "" {
+		paths = append(paths, core)
+	}
+	modules, err := addons.LoadModules(paths)
+	if err != nil {
+		log.Fatalf("Error reading addons paths: %v", err)
+	}
+	return modules
+}
+
+func printField(label, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Printf("  %-18s %s\n", label+":", value)
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
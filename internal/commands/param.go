@@ -0,0 +1,301 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/output"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// NewParamCmd represents the param command
+func NewParamCmd() *cobra.Command {
+	opts := &dbOptions{}
+	cmd := &cobra.Command{
+		Use:   "param",
+		Short: "Get, set and compare system parameters (ir_config_parameter)",
+		Long: `Manage the system parameters stored in ir_config_parameter.
+
+Odoo caches parameters in each server process, so a running server may
+keep the old values until it is restarted.`,
+	}
+	cmd.PersistentFlags().StringVarP(&opts.configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.PersistentFlags().StringVarP(&opts.dbName, "database", "d", "", "Database name")
+
+	cmd.AddCommand(
+		newParamGetCmd(opts),
+		newParamSetCmd(opts),
+		newParamUnsetCmd(opts),
+		newParamListCmd(opts),
+		newParamApplyCmd(opts),
+		newParamDiffCmd(opts),
+	)
+	return cmd
+}
+
+func newParamGetCmd(opts *dbOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get key",
+		Short: "Print the value of a parameter",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+			value, ok, err := db.LookupConfigParameter(cmd.Context(), conn, args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			if !ok {
+				log.Fatalf("Parameter %s is not set in %s", args[0], opts.dbName)
+			}
+			fmt.Println(value)
+		},
+	}
+}
+
+func newParamSetCmd(opts *dbOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set key value",
+		Short: "Set the value of a parameter",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+			if err := db.SetConfigParameter(cmd.Context(), conn, args[0], args[1]); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("✅ %s = %s\n", args[0], args[1])
+		},
+	}
+}
+
+func newParamUnsetCmd(opts *dbOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset key",
+		Short: "Delete a parameter",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+			deleted, err := db.DeleteConfigParameter(cmd.Context(), conn, args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			if !deleted {
+				fmt.Printf("Parameter %s was not set\n", args[0])
+				return
+			}
+			fmt.Printf("🗑️  Deleted %s\n", args[0])
+		},
+	}
+}
+
+func newParamListCmd(opts *dbOptions) *cobra.Command {
+	var (
+		prefix string
+		format string
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List parameters",
+		Run: func(cmd *cobra.Command, args []string) {
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+			params, err := db.ListConfigParameters(cmd.Context(), conn, prefix)
+			if err != nil {
+				log.Fatal(err)
+			}
+			table := output.NewTable("Key", "Value")
+			for _, p := range params {
+				table.AddRow(p.Key, p.Value)
+			}
+			if err := output.Render(os.Stdout, format, table); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&prefix, "prefix", "", "Only list keys starting with this prefix, e.g. web.")
+	output.AddFormatFlag(cmd, &format)
+	return cmd
+}
+
+func newParamApplyCmd(opts *dbOptions) *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "apply params.yml",
+		Short: "Enforce the parameters declared in a YAML file",
+		Long: `Set every parameter declared in a YAML file of key: value pairs, and
+delete the ones declared as null. Booleans are written as True/False, as
+Odoo expects. Parameters not in the file are left untouched.
+
+Example params.yml:
+  web.base.url: http://localhost:8069
+  web.base.url.freeze: true
+  report.url: http://localhost:8069
+  database.enterprise_code: null`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			desired, err := readParamsFile(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			ctx := cmd.Context()
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+
+			keys := make([]string, 0, len(desired))
+			for key := range desired {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			var changed, deleted, unchanged int
+			for _, key := range keys {
+				want := desired[key]
+				current, exists, err := db.LookupConfigParameter(ctx, conn, key)
+				if err != nil {
+					log.Fatal(err)
+				}
+				switch {
+				case want == nil && !exists, want != nil && exists && current == *want:
+					unchanged++
+					continue
+				case want == nil:
+					fmt.Printf("- %s (was %s)\n", key, current)
+					if !dryRun {
+						if _, err := db.DeleteConfigParameter(ctx, conn, key); err != nil {
+							log.Fatal(err)
+						}
+					}
+					deleted++
+				default:
+					if exists {
+						fmt.Printf("~ %s: %s -> %s\n", key, current, *want)
+					} else {
+						fmt.Printf("+ %s = %s\n", key, *want)
+					}
+					if !dryRun {
+						if err := db.SetConfigParameter(ctx, conn, key, *want); err != nil {
+							log.Fatal(err)
+						}
+					}
+					changed++
+				}
+			}
+			verb := "Applied"
+			if dryRun {
+				verb = "Would apply"
+			}
+			fmt.Printf("✅ %s to %s: %d set, %d deleted, %d unchanged\n", verb, opts.dbName, changed, deleted, unchanged)
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only show the changes")
+	return cmd
+}
+
+func newParamDiffCmd(opts *dbOptions) *cobra.Command {
+	var (
+		prefix string
+		format string
+	)
+	cmd := &cobra.Command{
+		Use:   "diff db1 db2",
+		Short: "Compare the parameters of two databases",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			values := make([]map[string]string, 2)
+			for i, name := range args {
+				conn, err := connectOdooDB(ctx, opts.configPath, name)
+				if err != nil {
+					log.Fatalf("Error connecting to %s: %v", name, err)
+				}
+				params, err := db.ListConfigParameters(ctx, conn, prefix)
+				db.CloseDB(conn)
+				if err != nil {
+					log.Fatal(err)
+				}
+				values[i] = make(map[string]string, len(params))
+				for _, p := range params {
+					values[i][p.Key] = p.Value
+				}
+			}
+
+			keySet := make(map[string]bool)
+			for _, v := range values {
+				for key := range v {
+					keySet[key] = true
+				}
+			}
+			keys := make([]string, 0, len(keySet))
+			for key := range keySet {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			table := output.NewTable("Key", args[0], args[1])
+			for _, key := range keys {
+				a, inA := values[0][key]
+				b, inB := values[1][key]
+				if inA == inB && a == b {
+					continue
+				}
+				table.AddRow(key, paramCell(a, inA), paramCell(b, inB))
+			}
+			if len(table.Rows) == 0 {
+				fmt.Fprintf(os.Stderr, "✅ No differences between %s and %s\n", args[0], args[1])
+				return
+			}
+			if err := output.Render(os.Stdout, format, table); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&prefix, "prefix", "", "Only compare keys starting with this prefix")
+	output.AddFormatFlag(cmd, &format)
+	return cmd
+}
+
+// readParamsFile reads the desired parameters; a nil value means unset
+func readParamsFile(path string) (map[string]*string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	raw := make(map[string]interface{})
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	params := make(map[string]*string, len(raw))
+	for key, value := range raw {
+		var s string
+		switch v := value.(type) {
+		case nil:
+			params[key] = nil
+			continue
+		case bool:
+			// Odoo stores booleans as Python literals
+			s = "False"
+			if v {
+				s = "True"
+			}
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("%s: value of %s must be a scalar", path, key)
+		default:
+			s = fmt.Sprint(v)
+		}
+		params[key] = &s
+	}
+	return params, nil
+}
+
+func paramCell(value string, ok bool) string {
+	if !ok {
+		return "(unset)"
+	}
+	return value
+}
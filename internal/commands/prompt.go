@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// stdin is shared by the prompts so that buffered input is not lost
+// between questions
+var stdin = bufio.NewReader(os.Stdin)
+
+// confirm asks a yes/no question on the terminal; assumeYes skips it
+func confirm(question string, assumeYes bool) bool {
+	if assumeYes {
+		return true
+	}
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := stdin.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes", "s", "si", "sí":
+		return true
+	}
+	return false
+}
+
+// ask prompts for a value on the terminal, returning def on empty input
+func ask(question, def string) string {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", question, def)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+	answer, err := stdin.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if err != nil || answer == "" {
+		return def
+	}
+	return answer
+}
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/mjavint/ocli/pkg/instance"
+	"github.com/mjavint/ocli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// NewPsCmd represents the ps command
+func NewPsCmd() *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "ps",
+		Short: "List running Odoo instances",
+		Long: `List the Odoo instances started with ocli start that are still running,
+with their PID, ports, database and Odoo version.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !output.ValidFormat(format) {
+				log.Fatalf("Unsupported output format: %s", format)
+			}
+			running, err := newInstanceRegistry().Running()
+			if err != nil {
+				log.Fatalf("Error listing instances: %v", err)
+			}
+
+			table := output.NewTable("Name", "PID", "HTTP", "Gevent", "Database", "Version", "Uptime")
+			for _, inst := range running {
+				table.AddRow(inst.Name, inst.PID, inst.HTTPPort, inst.GeventPort,
+					orDash(inst.Database), orDash(inst.Version),
+					time.Since(inst.StartedAt).Truncate(time.Second))
+			}
+			if err := output.Render(os.Stdout, format, table); err != nil {
+				log.Fatalf("Error rendering instances: %v", err)
+			}
+		},
+	}
+	output.AddFormatFlag(cmd, &format)
+	return cmd
+}
+
+// NewStopCmd represents the stop command
+func NewStopCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stop [name]",
+		Short: "Stop a running Odoo instance",
+		Long:  `Stop an Odoo instance started with ocli start (default: "default").`,
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := instance.DefaultName
+			if len(args) == 1 {
+				name = args[0]
+			}
+			registry := newInstanceRegistry()
+			inst, err := registry.Load(name)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if !inst.Running() {
+				registry.Remove(name)
+				fmt.Printf("Instance %s is not running\n", name)
+				return
+			}
+
+			fmt.Printf("🛑 Stopping instance %s (PID %d)...\n", name, inst.PID)
+			if err := instance.Terminate(inst.PID); err != nil {
+				log.Fatalf("Error stopping instance: %v", err)
+			}
+			deadline := time.Now().Add(10 * time.Second)
+			for inst.Running() && time.Now().Before(deadline) {
+				time.Sleep(200 * time.Millisecond)
+			}
+			if inst.Running() {
+				log.Fatalf("Instance %s did not stop within 10s", name)
+			}
+			if err := registry.Remove(name); err != nil {
+				log.Fatalf("Error cleaning instance state: %v", err)
+			}
+			fmt.Printf("✅ Instance %s stopped\n", name)
+		},
+	}
+	return cmd
+}
+
+// orDash renders empty values as "-" in tables
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -43,6 +43,9 @@ Example:
 				log.Fatal("New database name is required. Use --new-db or -n to specify it.")
 			}
 
+			// Odoo terminates the sessions of the database before renaming it
+			showConnections(cmd.Context(), configPath, dbName)
+
 			// Execute odoo-bin db duplicate command
 			cmdArgs := []string{"db", "-c", configPath, "rename", dbName, newName}
 
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/mjavint/ocli/pkg/addons"
+	"github.com/mjavint/ocli/pkg/config"
+	"github.com/mjavint/ocli/pkg/odoo"
+	"github.com/mjavint/ocli/pkg/scaffold"
+	"github.com/spf13/cobra"
+)
+
+// NewScaffoldCmd represents the scaffold command
+func NewScaffoldCmd() *cobra.Command {
+	var (
+		configPath string
+		path       string
+		tmpl       string
+		author     string
+		license    string
+		list       bool
+		noUpdate   bool
+	)
+	cmd := &cobra.Command{
+		Use:   "scaffold name",
+		Short: "Create a new module from a template",
+		Long: `Create a new module in --path from a template. The builtin "default"
+template creates the manifest, a model with its views, access rights,
+tests and an i18n directory; "minimal" only creates the manifest.
+
+The module version is prefixed with the Odoo series detected from
+odoo-bin. Author, license and website default to the scaffold section of
+ocli.yml, where teams can also register their own template directories:
+
+  scaffold:
+    author: My Company
+    license: LGPL-3
+    templates:
+      company: /workspace/templates/company
+
+Files ending in .tmpl are rendered with Go text/template, and file names
+may use the same fields, e.g. models/{{.Name}}.py.tmpl. Afterwards --path
+is appended to the addons_path of odoo.conf, unless it is already there,
+so the module is discoverable.
+
+Example:
+  ocli scaffold sale_custom --path /workspace/custom-addons
+  ocli scaffold sale_report -p ./addons -t company`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if list {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			custom := config.AppConfig.Scaffold.Templates
+			if list {
+				for _, name := range scaffold.Templates(custom) {
+					source := "builtin"
+					if dir, ok := custom[name]; ok {
+						source = dir
+					}
+					fmt.Printf("  %-18s %s\n", name, source)
+				}
+				return
+			}
+
+			name := args[0]
+			if !addons.ValidModuleName(name) {
+				log.Fatalf("Invalid module name %q: use lowercase letters, digits and underscores", name)
+			}
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if author == "" {
+				author = config.AppConfig.Scaffold.Author
+			}
+			if license == "" {
+				license = config.AppConfig.Scaffold.License
+			}
+			series, err := odoo.DetectVersion(config.AppConfig.Odoo.OdooBin)
+			if err != nil {
+				fmt.Printf("⚠️ Odoo version not detected, the version will have no series prefix: %v\n", err)
+			}
+
+			data := scaffold.NewData(name, series, author, license, config.AppConfig.Scaffold.Website)
+			dest := filepath.Join(absPath, name)
+			created, err := scaffold.Render(tmpl, custom, dest, data)
+			if err != nil {
+				log.Fatalf("Error creating module %s: %v", name, err)
+			}
+			fmt.Printf("✅ Module %s created in %s (%d files)\n", name, dest, len(created))
+			for _, file := range created {
+				rel, _ := filepath.Rel(dest, file)
+				fmt.Printf("  %s\n", rel)
+			}
+			if noUpdate {
+				return
+			}
+
+			paths := resolveAddonsPaths(configPath)
+			if len(paths) == 0 {
+				fmt.Println("⚠️ addons_path not updated: no addons paths in odoo.conf or ocli.yml")
+				return
+			}
+			for _, p := range paths {
+				if abs, err := filepath.Abs(p); err == nil && abs == absPath {
+					return
+				}
+			}
+			fmt.Println()
+			if err := writeAddonsPath(configPath, append(paths, absPath)); err != nil {
+				fmt.Printf("⚠️ addons_path not updated: %v\n", err)
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.Flags().StringVarP(&path, "path", "p", ".", "Addons directory to create the module in")
+	cmd.Flags().StringVarP(&tmpl, "template", "t", scaffold.DefaultTemplate, "Template to use")
+	cmd.Flags().StringVar(&author, "author", "", "Module author (default: scaffold.author from ocli.yml)")
+	cmd.Flags().StringVar(&license, "license", "", "Module license (default: scaffold.license from ocli.yml, or LGPL-3)")
+	cmd.Flags().BoolVar(&list, "list", false, "List the available templates")
+	cmd.Flags().BoolVar(&noUpdate, "no-update", false, "Do not update the addons_path afterwards")
+	return cmd
+}
+
+// underAnyRoot reports whether dir is one of roots or inside one of them
+func underAnyRoot(dir string, roots []string) bool {
+	for _, root := range roots {
+		root, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// NewSQLCmd represents the sql command
+func NewSQLCmd() *cobra.Command {
+	opts := &dbOptions{}
+	var (
+		file   string
+		dryRun bool
+		noTx   bool
+		format string
+	)
+	cmd := &cobra.Command{
+		Use:   "sql [query]",
+		Short: "Run SQL on a database with the credentials of odoo.conf",
+		Long: `Run a query on a database with the connection parameters of odoo.conf
+and print its rows in any of the output formats, or the number of rows
+changed by INSERT, UPDATE and DELETE.
+
+With --file a script of several statements is run. Both run in one
+transaction; with --dry-run it is rolled back, to see the effect of a
+change without keeping it. Statements such as BEGIN and COMMIT are
+refused then, as they would end that transaction early. With
+--no-transaction the statements of the script are run one by one and each
+is committed as it succeeds, for scripts managing their own transactions
+and for statements that cannot run in one, e.g. CREATE INDEX CONCURRENTLY.
+
+Example:
+  ocli sql -d database_name "SELECT login, active FROM res_users"
+  ocli sql -d database_name -o csv "SELECT * FROM res_partner" > partners.csv
+  ocli sql -d database_name --dry-run "UPDATE res_users SET active = false WHERE id > 2"
+  ocli sql -d database_name -f fix.sql`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if (file == "") == (len(args) == 0) {
+				log.Fatal("Give either a query or --file")
+			}
+			if dryRun && noTx {
+				log.Fatal("--dry-run needs a transaction, it cannot be used with --no-transaction")
+			}
+			if !output.ValidFormat(format) {
+				log.Fatalf("unsupported output format: %s", format)
+			}
+			ctx := cmd.Context()
+
+			if file != "" {
+				script, err := readScript(file)
+				if err != nil {
+					log.Fatal(err)
+				}
+				conn := opts.connect(cmd)
+				defer db.CloseDB(conn)
+				start := time.Now()
+				if err := db.RunScript(ctx, conn, script, !noTx, dryRun); err != nil {
+					if noTx {
+						log.Fatalf("🔴 %s failed, the statements before the error were kept: %v", file, err)
+					}
+					log.Fatalf("🔴 %s failed, nothing was committed: %v", file, err)
+				}
+				if dryRun {
+					fmt.Printf("♻️  %s ran in %s and was rolled back (dry run)\n", file, time.Since(start).Round(time.Millisecond))
+					return
+				}
+				fmt.Printf("✅ %s ran in %s\n", file, time.Since(start).Round(time.Millisecond))
+				return
+			}
+
+			conn := opts.connect(cmd)
+			defer db.CloseDB(conn)
+			result, err := db.RunQuery(ctx, conn, args[0], dryRun)
+			if err != nil {
+				log.Fatalf("🔴 %v", err)
+			}
+			switch {
+			case result.Affected >= 0:
+				fmt.Printf("%d row(s) affected\n", result.Affected)
+			case result.Columns == nil:
+				fmt.Println("✅ OK")
+			default:
+				table := output.NewTable(result.Columns...)
+				table.Rows = result.Rows
+				if err := output.Render(os.Stdout, format, table); err != nil {
+					log.Fatal(err)
+				}
+				if format == output.FormatTable {
+					fmt.Fprintf(os.Stderr, "(%d row(s))\n", len(result.Rows))
+				}
+			}
+			if dryRun {
+				fmt.Fprintln(os.Stderr, "♻️  Rolled back (dry run)")
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&opts.configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.Flags().StringVarP(&opts.dbName, "database", "d", "", "Database name")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "SQL script to run, - for standard input")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Roll back instead of committing")
+	cmd.Flags().BoolVar(&noTx, "no-transaction", false, "Run and commit the statements of the script one by one")
+	output.AddFormatFlag(cmd, &format)
+	return cmd
+}
+
+// NewPsqlCmd represents the psql command
+func NewPsqlCmd() *cobra.Command {
+	opts := &dbOptions{}
+	cmd := &cobra.Command{
+		Use:   "psql [-- psql-args...]",
+		Short: "Open psql on a database with the credentials of odoo.conf",
+		Long: `Run psql on a database with PGHOST, PGPORT, PGUSER, PGPASSWORD and
+PGDATABASE set from odoo.conf. Arguments after -- are passed to psql.
+
+Example:
+  ocli psql -d database_name
+  ocli psql -d database_name -- -c "SELECT count(*) FROM res_partner"`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if opts.dbName == "" {
+				log.Fatal("Database name is required. Use --database or -d to specify it.")
+			}
+			pgCfg, err := loadPGConfig(opts.configPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			psql, err := exec.LookPath("psql")
+			if err != nil {
+				log.Fatal("psql not found in PATH, install the PostgreSQL client")
+			}
+
+			child := exec.Command(psql, args...)
+			child.Stdin, child.Stdout, child.Stderr = os.Stdin, os.Stdout, os.Stderr
+			child.Env = append(os.Environ(),
+				"PGHOST="+pgCfg.Host,
+				"PGPORT="+strconv.Itoa(pgCfg.Port),
+				"PGUSER="+pgCfg.User,
+				"PGPASSWORD="+pgCfg.Password,
+				"PGDATABASE="+opts.dbName,
+				"PGAPPNAME=ocli psql",
+			)
+			// Ctrl+C is for psql, to cancel the running query
+			signal.Ignore(os.Interrupt)
+			if err := child.Run(); err != nil {
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					os.Exit(exitErr.ExitCode())
+				}
+				log.Fatalf("Error running psql: %v", err)
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&opts.configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.Flags().StringVarP(&opts.dbName, "database", "d", "", "Database name")
+	return cmd
+}
+
+// readScript reads a SQL file, or standard input for -
+func readScript(path string) (string, error) {
+	var content []byte
+	var err error
+	if path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(content), nil
+}
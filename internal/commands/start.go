@@ -3,38 +3,366 @@ package commands
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/mjavint/ocli/pkg/config"
+	"github.com/mjavint/ocli/pkg/instance"
+	"github.com/mjavint/ocli/pkg/odoo"
 	"github.com/spf13/cobra"
 )
 
+// Odoo describes one named Odoo server instance
 type Odoo struct {
+	name       string
 	odooBin    string
 	configPath string
+	database   string
+	httpPort   int
+	geventPort int
+	dataDir    string
+	logFile    string
+	pidFile    string
+	version    string
+	registry   *instance.Registry
+	// debugPort runs odoo-bin under debugpy listening on it when set
+	debugPort int
+	python    string
+	waitDebug bool
 }
 
+// defaultDebugPort is the port debugpy listens on by default
+const defaultDebugPort = 5678
+
 // initCmd represents the init command
 func NewStartOdooCmd() *cobra.Command {
+	var (
+		odooBin    string
+		configPath string
+		dbName     string
+		httpPort   int
+		geventPort int
+		dataDir    string
+		detach     bool
+		debug      bool
+		debugPort  int
+		python     string
+		waitDebug  bool
+	)
 	cmd := &cobra.Command{
-		Use:   "start",
+		Use:   "start [name]",
 		Short: "Odoo Start Server",
-		Long:  `Start the Odoo server with specified addons`,
+		Long: `Start the Odoo server with specified addons.
+
+Each server runs as a named instance (default: "default") with its own
+configuration, ports, data dir and pidfile. Named instances can be
+declared under "instances" in ocli.yml; ports that are not set are
+assigned from the free range configured under "ports".
+
+Example:
+  ocli start
+  ocli start v18 -d v18_db --detach
+  ocli start --debug --debug-port 5678
+  ocli ps
+
+With --debug, odoo-bin runs under debugpy (pip install debugpy) so an
+editor can attach to the port; see ocli ide vscode.`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			cfg := &Odoo{
-				odooBin:    config.AppConfig.Odoo.OdooBin,    // Ruta al binario de Odoo
-				configPath: config.AppConfig.Odoo.ConfigFile, // Ruta al archivo de configuración
+			name := instance.DefaultName
+			if len(args) == 1 {
+				name = args[0]
+			}
+			cfg, err := newOdooInstance(name)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if odooBin != "" {
+				cfg.odooBin = odooBin
+			}
+			if configPath != "" {
+				cfg.configPath = configPath
+			}
+			if dbName != "" {
+				cfg.database = dbName
+			}
+			if httpPort != 0 {
+				cfg.httpPort = httpPort
+			}
+			if geventPort != 0 {
+				cfg.geventPort = geventPort
+			}
+			if dataDir != "" {
+				cfg.dataDir = dataDir
+			}
+			if debug {
+				cfg.debugPort = debugPort
+				cfg.python = python
+				cfg.waitDebug = waitDebug
+			}
+
+			if err := cfg.assignPorts(); err != nil {
+				log.Fatal(err)
+			}
+			if detach {
+				err = cfg.startDetached()
+			} else {
+				err = cfg.startOdooServer()
+			}
+			if err != nil {
+				log.Fatal(err)
 			}
-			cfg.startOdooServer()
 		},
 	}
+	cmd.Flags().StringVarP(&odooBin, "bin", "b", "", "Path to the Odoo binary")
+	cmd.Flags().StringVarP(&configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.Flags().StringVarP(&dbName, "database", "d", "", "Database to serve")
+	cmd.Flags().IntVar(&httpPort, "http-port", 0, "HTTP port (default: auto-assigned)")
+	cmd.Flags().IntVar(&geventPort, "gevent-port", 0, "Gevent/longpolling port (default: auto-assigned)")
+	cmd.Flags().StringVar(&dataDir, "data-dir", "", "Odoo data directory (filestore, sessions)")
+	cmd.Flags().BoolVar(&detach, "detach", false, "Run in the background, logging to the instance log file")
+	cmd.Flags().BoolVar(&debug, "debug", false, "Run Odoo under debugpy")
+	cmd.Flags().IntVar(&debugPort, "debug-port", defaultDebugPort, "Port debugpy listens on")
+	cmd.Flags().StringVar(&python, "python", defaultPython(), "Python interpreter used to run debugpy")
+	cmd.Flags().BoolVar(&waitDebug, "wait-for-client", false, "Wait for the debugger to attach before starting Odoo")
 	return cmd
 }
 
+// newInstanceRegistry returns the registry of instances of the current project
+func newInstanceRegistry() *instance.Registry {
+	dir := filepath.Join(config.StateDir, "instances")
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return instance.NewRegistry(dir)
+}
+
+// newOdooInstance builds an instance from the odoo section of ocli.yml and
+// the overrides declared for name under instances.
+func newOdooInstance(name string) (*Odoo, error) {
+	if !instance.ValidName(name) {
+		return nil, fmt.Errorf("invalid instance name: %s", name)
+	}
+
+	registry := newInstanceRegistry()
+	if current, err := registry.Load(name); err == nil && current.Running() {
+		return nil, fmt.Errorf("instance %s is already running with PID %d", name, current.PID)
+	}
+
+	cfg := &Odoo{
+		name:       name,
+		odooBin:    config.AppConfig.Odoo.OdooBin,    // Ruta al binario de Odoo
+		configPath: config.AppConfig.Odoo.ConfigFile, // Ruta al archivo de configuración
+		pidFile:    registry.PIDFile(name),
+		registry:   registry,
+	}
+	if override, ok := config.AppConfig.Instances[name]; ok {
+		if override.OdooBin != "" {
+			cfg.odooBin = override.OdooBin
+		}
+		if override.ConfigFile != "" {
+			cfg.configPath = override.ConfigFile
+		}
+		cfg.database = override.Database
+		cfg.httpPort = override.HTTPPort
+		cfg.geventPort = override.GeventPort
+		cfg.dataDir = override.DataDir
+	}
+	return cfg, nil
+}
+
+// assignPorts fills unset ports. The default instance keeps the ports of
+// odoo.conf while they are free; any other port comes from the free range.
+func (cfg *Odoo) assignPorts() error {
+	used, err := cfg.registry.UsedPorts(cfg.name)
+	if err != nil {
+		return err
+	}
+
+	if cfg.name == instance.DefaultName && (cfg.httpPort == 0 || cfg.geventPort == 0) {
+		options, err := config.ReadOdooConf(cfg.configPath)
+		if err != nil {
+			return err
+		}
+		if cfg.httpPort == 0 {
+			cfg.httpPort = confPort(options, 8069, "http_port", "xmlrpc_port")
+			if used[cfg.httpPort] {
+				cfg.httpPort = 0
+			}
+		}
+		if cfg.geventPort == 0 {
+			cfg.geventPort = confPort(options, 8072, "gevent_port", "longpolling_port")
+			if used[cfg.geventPort] {
+				cfg.geventPort = 0
+			}
+		}
+	}
+	used[cfg.httpPort] = true
+	used[cfg.geventPort] = true
+
+	from, to := config.AppConfig.Ports.From, config.AppConfig.Ports.To
+	if cfg.httpPort == 0 {
+		if cfg.httpPort, err = instance.FreePort(from, to, used); err != nil {
+			return err
+		}
+	}
+	if cfg.geventPort == 0 {
+		if cfg.geventPort, err = instance.FreePort(from, to, used); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// confPort returns the first of keys set in odoo.conf, or def
+func confPort(options map[string]string, def int, keys ...string) int {
+	for _, key := range keys {
+		if port, err := strconv.Atoi(options[key]); err == nil && port > 0 {
+			return port
+		}
+	}
+	return def
+}
+
+// confValue returns an odoo.conf option, treating False/None as unset
+func confValue(options map[string]string, key string) string {
+	if value := options[key]; value != "False" && value != "None" {
+		return value
+	}
+	return ""
+}
+
+// args builds the odoo-bin command line for the instance
+func (cfg *Odoo) args() []string {
+	geventFlag := "--gevent-port"
+	if major := odoo.MajorVersion(cfg.version); major > 0 && major < 16 {
+		geventFlag = "--longpolling-port"
+	}
+
+	args := []string{
+		"-c", cfg.configPath,
+		"--http-port", strconv.Itoa(cfg.httpPort),
+		geventFlag, strconv.Itoa(cfg.geventPort),
+		"--pidfile", cfg.pidFile,
+	}
+	if cfg.database != "" {
+		args = append(args, "-d", cfg.database)
+	}
+	if cfg.dataDir != "" {
+		args = append(args, "--data-dir", cfg.dataDir)
+	}
+	if cfg.logFile != "" {
+		args = append(args, "--logfile", cfg.logFile)
+	}
+	return args
+}
+
+// command returns the program and arguments that run the instance,
+// wrapping odoo-bin with debugpy when debugging
+func (cfg *Odoo) command() (string, []string) {
+	if cfg.debugPort == 0 {
+		return cfg.odooBin, cfg.args()
+	}
+	args := []string{"-m", "debugpy", "--listen", "127.0.0.1:" + strconv.Itoa(cfg.debugPort)}
+	if cfg.waitDebug {
+		args = append(args, "--wait-for-client")
+	}
+	args = append(args, cfg.odooBin)
+	return cfg.python, append(args, cfg.args()...)
+}
+
+// defaultPython returns the usual name of the Python 3 interpreter
+func defaultPython() string {
+	if runtime.GOOS == "windows" {
+		return "python"
+	}
+	return "python3"
+}
+
+// register records the running process in the instance registry
+func (cfg *Odoo) register(pid int, detached bool) error {
+	options, _ := config.ReadOdooConf(cfg.configPath)
+	database := cfg.database
+	if database == "" {
+		database = confValue(options, "db_name")
+	}
+	logFile := cfg.logFile
+	if logFile == "" {
+		logFile = confValue(options, "logfile")
+	}
+	return cfg.registry.Save(&instance.Instance{
+		Name:       cfg.name,
+		OdooBin:    cfg.odooBin,
+		ConfigFile: cfg.configPath,
+		Database:   database,
+		DataDir:    cfg.dataDir,
+		LogFile:    logFile,
+		HTTPPort:   cfg.httpPort,
+		GeventPort: cfg.geventPort,
+		PID:        pid,
+		Version:    cfg.version,
+		Detached:   detached,
+		StartedAt:  time.Now(),
+	})
+}
+
+// startDetached starts the instance in the background logging to a file
+func (cfg *Odoo) startDetached() error {
+	cfg.version, _ = odoo.DetectVersion(cfg.odooBin)
+	if cfg.logFile == "" {
+		cfg.logFile = cfg.registry.LogFile(cfg.name)
+	}
+	if err := os.MkdirAll(cfg.registry.Dir(), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", cfg.registry.Dir(), err)
+	}
+	out, err := os.OpenFile(cfg.logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer out.Close()
+
+	program, args := cfg.command()
+	cmd := exec.Command(program, args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	instance.Detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start Odoo: %w", err)
+	}
+	pid := cmd.Process.Pid
+	if err := cfg.register(pid, true); err != nil {
+		return err
+	}
+
+	// Reap the child if it dies while we wait, so it is not seen as alive
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+		cfg.registry.Remove(cfg.name)
+		return fmt.Errorf("Odoo exited right after starting, see %s", cfg.logFile)
+	case <-time.After(2 * time.Second):
+	}
+
+	fmt.Printf("✅ Instance %s started with PID %d (http %d, gevent %d)\n",
+		cfg.name, pid, cfg.httpPort, cfg.geventPort)
+	cfg.printDebug()
+	fmt.Printf("Logs: %s\n", cfg.logFile)
+	return nil
+}
+
 func (cfg *Odoo) startOdooServer() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -44,8 +372,11 @@ func (cfg *Odoo) startOdooServer() error {
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
 	defer signal.Stop(signalChan)
 
+	cfg.version, _ = odoo.DetectVersion(cfg.odooBin)
+
 	// Create and configure command
-	cmd := exec.CommandContext(ctx, cfg.odooBin, "-c", cfg.configPath)
+	program, args := cfg.command()
+	cmd := exec.CommandContext(ctx, program, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
@@ -55,7 +386,14 @@ func (cfg *Odoo) startOdooServer() error {
 		return fmt.Errorf("failed to start Odoo: %w", err)
 	}
 
-	fmt.Printf("✅ Odoo started with PID %d\n", cmd.Process.Pid)
+	if err := cfg.register(cmd.Process.Pid, false); err != nil {
+		fmt.Printf("⚠️ Failed to register instance %s: %v\n", cfg.name, err)
+	}
+	defer cfg.registry.Remove(cfg.name)
+
+	fmt.Printf("✅ Odoo started with PID %d (instance %s, http %d, gevent %d)\n",
+		cmd.Process.Pid, cfg.name, cfg.httpPort, cfg.geventPort)
+	cfg.printDebug()
 	fmt.Println("Press Ctrl+C to stop...")
 
 	// Wait for completion or signal
@@ -72,6 +410,17 @@ func (cfg *Odoo) startOdooServer() error {
 	}
 }
 
+func (cfg *Odoo) printDebug() {
+	if cfg.debugPort == 0 {
+		return
+	}
+	fmt.Printf("🐞 debugpy listening on 127.0.0.1:%d", cfg.debugPort)
+	if cfg.waitDebug {
+		fmt.Print(", waiting for the debugger to attach")
+	}
+	fmt.Println()
+}
+
 func (cfg *Odoo) handleShutdown(sig os.Signal, cmd *exec.Cmd, errChan <-chan error, cancel context.CancelFunc) error {
 	fmt.Printf("\n🛑 Received signal %v. Shutting down Odoo...\n", sig)
 
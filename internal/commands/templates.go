@@ -0,0 +1,364 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mjavint/ocli/pkg/addons"
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/odoo"
+	"github.com/mjavint/ocli/pkg/output"
+	"github.com/mjavint/ocli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// templatePrefix marks the cached template databases managed by ocli
+const templatePrefix = db.TemplatePrefix
+
+// templateSpec is everything that determines the content of a template
+type templateSpec struct {
+	Version string            `json:"version"`
+	Modules []string          `json:"modules"`
+	Demo    bool              `json:"demo"`
+	Lang    string            `json:"lang"`
+	Sources map[string]string `json:"sources"`
+}
+
+// templateMeta is stored as the comment of each template database
+type templateMeta struct {
+	Key      string    `json:"key"`
+	Version  string    `json:"version"`
+	Modules  []string  `json:"modules"`
+	Demo     bool      `json:"demo"`
+	Lang     string    `json:"lang"`
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// newTemplateSpec hashes the sources of the requested modules and of
+// every custom module they depend on, so that a change anywhere in their
+// dependencies gives a new key. The core addons next to odoo-bin are
+// covered by the version.
+func newTemplateSpec(odooBin, configPath string, modules []string, demo bool, lang string) (*templateSpec, error) {
+	version, _ := odoo.DetectVersion(odooBin)
+	spec := &templateSpec{
+		Version: version,
+		Modules: uniqueSorted(modules),
+		Demo:    demo,
+		Lang:    lang,
+		Sources: make(map[string]string),
+	}
+	local := loadLocalModules(configPath)
+	dirs := make(map[string]string, len(local))
+	for _, mod := range local {
+		dirs[mod.Name] = mod.Dir
+	}
+	graph := addons.NewGraph(local)
+	closure := make(map[string]bool)
+	for _, name := range spec.Modules {
+		if !graph.Has(name) {
+			continue
+		}
+		for _, dep := range graph.Closure(name) {
+			closure[dep] = true
+		}
+	}
+
+	var coreRoots []string
+	if odooBin != "" {
+		odooDir := filepath.Dir(odooBin)
+		coreRoots = []string{filepath.Join(odooDir, "addons"), filepath.Join(odooDir, "odoo", "addons")}
+	}
+	for name := range closure {
+		dir, err := filepath.Abs(dirs[name])
+		if err != nil {
+			return nil, err
+		}
+		if underAnyRoot(dir, coreRoots) {
+			continue
+		}
+		hash, err := addons.HashModule(dir)
+		if err != nil {
+			return nil, err
+		}
+		spec.Sources[name] = hash
+	}
+	return spec, nil
+}
+
+// key returns the cache key of the spec; json.Marshal sorts map keys, so
+// the encoding is stable
+func (s *templateSpec) key() string {
+	content, _ := json.Marshal(s)
+	sum := sha1.Sum(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// dbName returns the template database name for the spec
+func (s *templateSpec) dbName() string {
+	return templatePrefix + s.key()[:16]
+}
+
+// ensureTemplate returns the template database for spec, building it with
+// odoo-bin when no template matches the key yet
+func ensureTemplate(ctx context.Context, odooBin, configPath string, pgCfg *db.PGConfig, spec *templateSpec, quiet bool) (string, error) {
+	name := spec.dbName()
+	exists, err := db.DBExists(ctx, name, pgCfg)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		fmt.Printf("♻️  Using cached template %s\n", name)
+		return name, nil
+	}
+
+	fmt.Printf("🏗️  Building template %s (%s)\n", name, strings.Join(spec.Modules, ", "))
+	building := name + "_building"
+	if err := dropDatabaseAndFilestore(ctx, configPath, building, pgCfg); err != nil {
+		return "", err
+	}
+
+	cmdArgs := []string{
+		"-c", configPath, "-d", building,
+		"-i", strings.Join(spec.Modules, ","),
+		"--stop-after-init", "--logfile=",
+	}
+	if odoo.MajorVersion(spec.Version) >= 18 {
+		if spec.Demo {
+			cmdArgs = append(cmdArgs, "--with-demo")
+		}
+	} else if !spec.Demo {
+		cmdArgs = append(cmdArgs, "--without-demo=all")
+	}
+	if spec.Lang != "" {
+		cmdArgs = append(cmdArgs, "--load-language="+spec.Lang)
+	}
+	if err := runOdooStep(ctx, odooBin, cmdArgs, "Template build", quiet); err != nil {
+		dropDatabaseAndFilestore(ctx, configPath, building, pgCfg)
+		return "", err
+	}
+
+	if err := db.RenameDatabase(ctx, building, name, pgCfg); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filestoreDir(configPath, building)); err == nil {
+		if err := os.Rename(filestoreDir(configPath, building), filestoreDir(configPath, name)); err != nil {
+			return "", fmt.Errorf("failed to move template filestore: %w", err)
+		}
+	}
+
+	now := time.Now()
+	meta := templateMeta{
+		Key:      spec.key(),
+		Version:  spec.Version,
+		Modules:  spec.Modules,
+		Demo:     spec.Demo,
+		Lang:     spec.Lang,
+		Created:  now,
+		LastUsed: now,
+	}
+	if err := saveTemplateMeta(ctx, name, meta, pgCfg); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// cloneTemplate creates target from the template database and its filestore
+func cloneTemplate(ctx context.Context, configPath, template, target string, pgCfg *db.PGConfig) error {
+	if err := db.CreateDatabaseFromTemplate(ctx, target, template, pgCfg); err != nil {
+		return err
+	}
+
+	src := filestoreDir(configPath, template)
+	if _, err := os.Stat(src); err == nil {
+		if err := utils.CopyTree(src, filestoreDir(configPath, target)); err != nil {
+			return fmt.Errorf("failed to copy filestore: %w", err)
+		}
+	}
+
+	// Give the copy its own identity, as odoo-bin db duplicate does
+	conn, err := db.Connect(ctx, target, pgCfg)
+	if err != nil {
+		return err
+	}
+	defer db.CloseDB(conn)
+	uuid, err := newUUID()
+	if err != nil {
+		return err
+	}
+	if err := db.SetConfigParameter(ctx, conn, "database.uuid", uuid); err != nil {
+		return err
+	}
+	if err := db.SetConfigParameter(ctx, conn, "database.create_date", time.Now().UTC().Format(time.DateTime)); err != nil {
+		return err
+	}
+
+	if meta, err := loadTemplateMeta(ctx, template, pgCfg); err == nil {
+		meta.LastUsed = time.Now()
+		saveTemplateMeta(ctx, template, meta, pgCfg)
+	}
+	return nil
+}
+
+// dropDatabaseAndFilestore removes a database and its filestore
+func dropDatabaseAndFilestore(ctx context.Context, configPath, name string, pgCfg *db.PGConfig) error {
+	if err := db.DropDatabase(ctx, name, pgCfg); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filestoreDir(configPath, name)); err != nil {
+		return fmt.Errorf("failed to remove filestore: %w", err)
+	}
+	return nil
+}
+
+func saveTemplateMeta(ctx context.Context, name string, meta templateMeta, pgCfg *db.PGConfig) error {
+	content, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to encode template metadata: %w", err)
+	}
+	return db.SetDatabaseComment(ctx, name, string(content), pgCfg)
+}
+
+func loadTemplateMeta(ctx context.Context, name string, pgCfg *db.PGConfig) (templateMeta, error) {
+	templates, err := listTemplates(ctx, pgCfg)
+	if err != nil {
+		return templateMeta{}, err
+	}
+	for _, tpl := range templates {
+		if tpl.info.Name == name {
+			return tpl.meta, nil
+		}
+	}
+	return templateMeta{}, fmt.Errorf("template %s not found", name)
+}
+
+type cachedTemplate struct {
+	info db.DatabaseInfo
+	meta templateMeta
+}
+
+// listTemplates returns the template databases, skipping half-built ones
+func listTemplates(ctx context.Context, pgCfg *db.PGConfig) ([]cachedTemplate, error) {
+	infos, err := db.ListDatabasesWithPrefix(ctx, templatePrefix, pgCfg)
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]cachedTemplate, 0, len(infos))
+	for _, info := range infos {
+		if strings.HasSuffix(info.Name, "_building") {
+			continue
+		}
+		tpl := cachedTemplate{info: info}
+		json.Unmarshal([]byte(info.Comment), &tpl.meta)
+		templates = append(templates, tpl)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].meta.LastUsed.After(templates[j].meta.LastUsed)
+	})
+	return templates, nil
+}
+
+// newUUID returns a random RFC 4122 version 4 UUID
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate uuid: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// NewTemplatesCmd represents the templates command
+func NewTemplatesCmd() *cobra.Command {
+	var configPath string
+	cmd := &cobra.Command{
+		Use:   "templates",
+		Short: "Manage cached template databases",
+		Long: `Manage the template databases built by ocli initdb --cached.
+
+Templates are keyed by a hash of the Odoo version, module list, demo flag,
+language and the sources of the modules and their custom dependencies, and
+are stored as databases named
+` + templatePrefix + `<key>.`,
+	}
+	cmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+
+	var format string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List cached template databases",
+		Run: func(cmd *cobra.Command, args []string) {
+			pgCfg, err := loadPGConfig(configPath)
+			if err != nil {
+				log.Fatalf("Error resolviendo configuración: %v", err)
+			}
+			templates, err := listTemplates(cmd.Context(), pgCfg)
+			if err != nil {
+				log.Fatalf("Error listing templates: %v", err)
+			}
+			table := output.NewTable("Name", "Version", "Modules", "Demo", "Lang", "Size", "Created", "Last used")
+			for _, tpl := range templates {
+				table.AddRow(tpl.info.Name, orDash(tpl.meta.Version),
+					strings.Join(tpl.meta.Modules, ","), tpl.meta.Demo, orDash(tpl.meta.Lang),
+					db.FormatBytes(tpl.info.Size),
+					tpl.meta.Created.Format(time.DateTime), tpl.meta.LastUsed.Format(time.DateTime))
+			}
+			if err := output.Render(os.Stdout, format, table); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	output.AddFormatFlag(listCmd, &format)
+
+	var (
+		olderThan time.Duration
+		all       bool
+	)
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Drop cached templates not used recently",
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			pgCfg, err := loadPGConfig(configPath)
+			if err != nil {
+				log.Fatalf("Error resolviendo configuración: %v", err)
+			}
+			templates, err := listTemplates(ctx, pgCfg)
+			if err != nil {
+				log.Fatalf("Error listing templates: %v", err)
+			}
+
+			cutoff := time.Now().Add(-olderThan)
+			pruned := 0
+			var freed int64
+			for _, tpl := range templates {
+				if !all && tpl.meta.LastUsed.After(cutoff) {
+					continue
+				}
+				if err := dropDatabaseAndFilestore(ctx, configPath, tpl.info.Name, pgCfg); err != nil {
+					log.Fatalf("Error dropping template %s: %v", tpl.info.Name, err)
+				}
+				fmt.Printf("🗑️  Dropped %s\n", tpl.info.Name)
+				pruned++
+				freed += tpl.info.Size
+			}
+			fmt.Printf("Pruned %d template(s), %s freed\n", pruned, db.FormatBytes(freed))
+		},
+	}
+	pruneCmd.Flags().DurationVar(&olderThan, "older-than", 7*24*time.Hour, "Drop templates not used for this long")
+	pruneCmd.Flags().BoolVar(&all, "all", false, "Drop every cached template")
+
+	cmd.AddCommand(listCmd, pruneCmd)
+	return cmd
+}
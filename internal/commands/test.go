@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mjavint/ocli/pkg/config"
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/odoo"
+	"github.com/spf13/cobra"
+)
+
+// NewTestCmd represents the test command
+func NewTestCmd() *cobra.Command {
+	var (
+		odooBin    string
+		configPath string
+		dbName     string
+		modules    []string
+		tags       string
+		junitFile  string
+		keepDB     bool
+		dropDB     bool
+		quiet      bool
+	)
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Run Odoo module tests and write a JUnit report",
+		Long: `Run the tests of one or more modules with odoo-bin --test-enable
+--stop-after-init, parse the log for test results and tracebacks, and write
+a JUnit XML report plus a console summary. Exits non-zero on failures.
+
+Without --database, a throwaway database is created and dropped at the end
+unless --keep-db is set. A database given with --database is created if it
+does not exist and kept afterwards, unless --drop is set and it was created
+by this run. An existing database is reused: the modules already installed
+in it are upgraded and the others installed.
+
+Example:
+  ocli test -m sale_custom
+  ocli test -d testdb -m sale_custom --tags /sale_custom:TestSale.test_confirm --keep-db`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if odooBin == "" {
+				odooBin = config.AppConfig.Odoo.OdooBin
+			}
+			if configPath == "" {
+				configPath = config.AppConfig.Odoo.ConfigFile
+			}
+			modules = validModuleArgs(modules)
+			if len(modules) == 0 {
+				log.Fatal("Modules to test are required. Use --module or -m to specify them.")
+			}
+
+			ctx := cmd.Context()
+			pgCfg, err := loadPGConfig(configPath)
+			if err != nil {
+				log.Fatalf("Error resolviendo configuración: %v", err)
+			}
+			generated := dbName == ""
+			if generated {
+				dbName = fmt.Sprintf("ocli_test_%s", time.Now().Format("20060102_150405"))
+			}
+			exists, err := db.DBExists(ctx, dbName, pgCfg)
+			if err != nil {
+				log.Fatalf("Error checking database %s: %v", dbName, err)
+			}
+
+			toInstall, toUpdate := modules, []string(nil)
+			if !exists {
+				if err := db.CreateDatabase(ctx, dbName, pgCfg); err != nil {
+					log.Fatalf("Error creating database %s: %v", dbName, err)
+				}
+				fmt.Printf("🧪 Created test database %s\n", dbName)
+			} else {
+				fmt.Printf("🧪 Reusing database %s\n", dbName)
+				toInstall, toUpdate = splitInstalled(ctx, pgCfg, configPath, dbName, modules)
+			}
+
+			cmdArgs := []string{"-c", configPath, "-d", dbName}
+			if len(toInstall) > 0 {
+				cmdArgs = append(cmdArgs, "-i", strings.Join(toInstall, ","))
+			}
+			if len(toUpdate) > 0 {
+				cmdArgs = append(cmdArgs, "-u", strings.Join(toUpdate, ","))
+			}
+			cmdArgs = append(cmdArgs, "--test-enable", "--stop-after-init", "--logfile=")
+			if tags != "" {
+				cmdArgs = append(cmdArgs, "--test-tags", tags)
+			}
+
+			fmt.Printf("Executing: %s %v\n", odooBin, cmdArgs)
+			start := time.Now()
+			result, runErr := odoo.Run(ctx, odooBin, cmdArgs, odooEcho(quiet))
+			elapsed := time.Since(start)
+
+			// Only drop a database this run created, and one named with
+			// --database only when asked to
+			throwaway := !exists && ((generated && !keepDB) || dropDB)
+			if throwaway {
+				if err := db.DropDatabase(ctx, dbName, pgCfg); err != nil {
+					fmt.Printf("⚠️ Failed to drop test database %s: %v\n", dbName, err)
+				}
+				os.RemoveAll(filestoreDir(configPath, dbName))
+			} else if !exists {
+				fmt.Printf("Test database kept: %s\n", dbName)
+			}
+			if runErr != nil {
+				log.Fatal(runErr)
+			}
+
+			report := odoo.ParseTestResults(result.Records)
+			if junitFile != "" {
+				if err := writeJUnitReport(junitFile, report, elapsed); err != nil {
+					log.Fatal(err)
+				}
+			}
+			printTestSummary(report, elapsed)
+			if junitFile != "" {
+				fmt.Printf("JUnit report: %s\n", junitFile)
+			}
+
+			if report.Failed() || result.ExitCode != 0 {
+				if result.ExitCode != 0 && !report.Failed() {
+					fmt.Printf("🔴 Odoo exited with code %d\n", result.ExitCode)
+					if tb := result.FirstTraceback(); tb != "" {
+						fmt.Println(tb)
+					}
+				}
+				os.Exit(1)
+			}
+			if len(report.Cases) == 0 {
+				fmt.Println("🔴 No test ran, check --tags and that the modules have tests")
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&odooBin, "bin", "b", "", "Path to the Odoo binary")
+	cmd.Flags().StringVarP(&configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.Flags().StringVarP(&dbName, "database", "d", "", "Database to test in (default: a throwaway database)")
+	cmd.Flags().StringSliceVarP(&modules, "module", "m", nil, "Modules to install and test (comma separated)")
+	cmd.Flags().StringVar(&tags, "tags", "", "Odoo --test-tags specification")
+	cmd.Flags().StringVar(&junitFile, "junit", "junit.xml", "JUnit XML report path (empty to disable)")
+	cmd.Flags().BoolVar(&keepDB, "keep-db", false, "Keep the throwaway database for debugging")
+	cmd.Flags().BoolVar(&dropDB, "drop", false, "Drop the --database database at the end if this run created it")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Do not echo the Odoo output")
+	return cmd
+}
+
+// splitInstalled separates the modules to install from the ones already
+// installed in an existing database, which are upgraded instead
+func splitInstalled(ctx context.Context, pgCfg *db.PGConfig, configPath, dbName string, modules []string) (install, update []string) {
+	initialized, err := db.IsInitialized(ctx, dbName, pgCfg)
+	if err != nil {
+		log.Fatalf("Error checking database %s: %v", dbName, err)
+	}
+	if !initialized {
+		return modules, nil
+	}
+	conn, err := connectOdooDB(ctx, configPath, dbName)
+	if err != nil {
+		log.Fatalf("Error connecting to %s: %v", dbName, err)
+	}
+	defer db.CloseDB(conn)
+	installed := installedSet(ctx, conn)
+	for _, name := range modules {
+		if installed[name] {
+			update = append(update, name)
+		} else {
+			install = append(install, name)
+		}
+	}
+	return install, update
+}
+
+func writeJUnitReport(path string, report *odoo.TestReport, elapsed time.Duration) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+	return report.WriteJUnit(file, elapsed)
+}
+
+// printTestSummary prints failures with their details and the totals
+func printTestSummary(report *odoo.TestReport, elapsed time.Duration) {
+	for _, c := range report.Cases {
+		if c.Status != odoo.TestFailed && c.Status != odoo.TestErrored {
+			continue
+		}
+		fmt.Printf("\n🔴 %s: %s.%s\n", strings.ToUpper(c.Status), c.ClassName, c.Name)
+		if c.Details != "" {
+			fmt.Println(indent(c.Details, "    "))
+		}
+	}
+
+	fmt.Printf("\n%d test(s) in %s: %d passed, %d failed, %d error(s), %d skipped\n",
+		len(report.Cases), elapsed.Round(time.Second),
+		report.Count(odoo.TestPassed), report.Count(odoo.TestFailed),
+		report.Count(odoo.TestErrored), report.Count(odoo.TestSkipped))
+}
@@ -0,0 +1,231 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mjavint/ocli/pkg/addons"
+	"github.com/mjavint/ocli/pkg/config"
+	"github.com/mjavint/ocli/pkg/db"
+	"github.com/mjavint/ocli/pkg/odoo"
+	"github.com/spf13/cobra"
+)
+
+// moduleHashesParam is the ir_config_parameter where ocli keeps the hash
+// of each addon directory at the time it was last upgraded
+const moduleHashesParam = "ocli.module_hashes"
+
+// NewUpgradeCmd represents the upgrade command
+func NewUpgradeCmd() *cobra.Command {
+	var (
+		odooBin    string
+		configPath string
+		dbName     string
+		changed    bool
+		dryRun     bool
+		quiet      bool
+	)
+	cmd := &cobra.Command{
+		Use:   "upgrade [modules...]",
+		Short: "Upgrade Odoo modules in a database",
+		Long: `Upgrade modules with odoo-bin -u ... --stop-after-init.
+
+With --changed, ocli hashes every installed module found in the addons
+paths and only upgrades those whose hash differs from the one stored in
+the database (ir_config_parameter ` + moduleHashesParam + `). The stored
+hashes are refreshed after every successful upgrade. On the first run no
+hashes are stored yet, so every installed module is upgraded.
+
+Example:
+  ocli upgrade -d mydb sale_custom stock_custom
+  ocli upgrade -d mydb --changed`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if odooBin == "" {
+				odooBin = config.AppConfig.Odoo.OdooBin
+			}
+			if configPath == "" {
+				configPath = config.AppConfig.Odoo.ConfigFile
+			}
+			if dbName == "" {
+				log.Fatal("Database name is required. Use --database or -d to specify it.")
+			}
+			if !changed && len(args) == 0 {
+				log.Fatal("Specify the modules to upgrade or use --changed.")
+			}
+
+			ctx := cmd.Context()
+			conn, err := connectOdooDB(ctx, configPath, dbName)
+			if err != nil {
+				log.Fatalf("Error connecting to %s: %v", dbName, err)
+			}
+			defer db.CloseDB(conn)
+
+			paths := resolveAddonsPaths(configPath)
+			stored, err := loadModuleHashes(ctx, conn)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			modules := args
+			current := make(map[string]string)
+			if changed {
+				installed, err := db.GetInstalledModules(ctx, conn)
+				if err != nil {
+					log.Fatalf("Error reading installed modules: %v", err)
+				}
+				modules = nil
+				for _, name := range installed {
+					dir, ok := addons.FindModule(paths, name)
+					if !ok {
+						continue
+					}
+					hash, err := addons.HashModule(dir)
+					if err != nil {
+						log.Fatal(err)
+					}
+					current[name] = hash
+					if stored[name] != hash {
+						modules = append(modules, name)
+					}
+				}
+				modules = append(modules, args...)
+			}
+			modules = uniqueSorted(modules)
+
+			if len(modules) == 0 {
+				fmt.Println("✅ All modules are up to date")
+				return
+			}
+			fmt.Printf("📦 Modules to upgrade (%d): %s\n", len(modules), strings.Join(modules, ", "))
+			if dryRun {
+				return
+			}
+
+			// Hash explicitly requested modules too, so --changed skips them next time
+			for _, name := range modules {
+				if _, ok := current[name]; ok {
+					continue
+				}
+				if dir, ok := addons.FindModule(paths, name); ok {
+					if hash, err := addons.HashModule(dir); err == nil {
+						current[name] = hash
+					}
+				}
+			}
+
+			cmdArgs := []string{
+				"-c", configPath, "-d", dbName,
+				"-u", strings.Join(modules, ","),
+				"--stop-after-init", "--logfile=",
+			}
+			if err := runOdooStep(ctx, odooBin, cmdArgs, "Upgrade", quiet); err != nil {
+				log.Fatal(err)
+			}
+
+			for name, hash := range current {
+				stored[name] = hash
+			}
+			if err := saveModuleHashes(ctx, conn, stored); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("✅ Upgraded: %s\n", strings.Join(modules, ", "))
+		},
+	}
+	cmd.Flags().StringVarP(&odooBin, "bin", "b", "", "Path to the Odoo binary")
+	cmd.Flags().StringVarP(&configPath, "config", "c", "", "Odoo configuration file path (odoo.conf)")
+	cmd.Flags().StringVarP(&dbName, "database", "d", "", "Database to upgrade")
+	cmd.Flags().BoolVar(&changed, "changed", false, "Only upgrade modules whose source changed since the last upgrade")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only print the modules that would be upgraded")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Do not echo the Odoo output")
+	return cmd
+}
+
+// runOdooStep runs an odoo-bin --stop-after-init command, reporting the time
+// taken and the first traceback when it fails
+func runOdooStep(ctx context.Context, odooBin string, args []string, step string, quiet bool) error {
+	fmt.Printf("Executing: %s %v\n", odooBin, args)
+	start := time.Now()
+	result, err := odoo.Run(ctx, odooBin, args, odooEcho(quiet))
+	return reportOdooRun(step, start, result, err)
+}
+
+// odooEcho returns where to copy the Odoo output, nil when quiet
+func odooEcho(quiet bool) io.Writer {
+	if quiet {
+		return nil
+	}
+	return os.Stdout
+}
+
+// reportOdooRun prints the outcome of an odoo-bin run and returns an error
+// when it exited non-zero or logged errors
+func reportOdooRun(step string, start time.Time, result *odoo.RunResult, err error) error {
+	if err != nil {
+		return err
+	}
+	elapsed := time.Since(start).Round(time.Second)
+
+	errs := result.Errors()
+	if result.ExitCode == 0 && len(errs) == 0 {
+		fmt.Printf("⏱️  %s finished in %s\n", step, elapsed)
+		return nil
+	}
+
+	fmt.Printf("🔴 %s failed after %s (exit code %d, %d error(s))\n",
+		step, elapsed, result.ExitCode, len(errs))
+	if tb := result.FirstTraceback(); tb != "" {
+		fmt.Println("\nFirst traceback:")
+		fmt.Println(tb)
+	} else if len(errs) > 0 {
+		fmt.Println("\nFirst error:")
+		fmt.Println(errs[0].Raw())
+	}
+	return fmt.Errorf("%s failed", strings.ToLower(step))
+}
+
+// loadModuleHashes reads the module hashes stored by ocli in the database
+func loadModuleHashes(ctx context.Context, conn *sql.DB) (map[string]string, error) {
+	hashes := make(map[string]string)
+	value, err := db.GetConfigParameter(ctx, conn, moduleHashesParam)
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return hashes, nil
+	}
+	if err := json.Unmarshal([]byte(value), &hashes); err != nil {
+		return nil, fmt.Errorf("invalid %s parameter: %w", moduleHashesParam, err)
+	}
+	return hashes, nil
+}
+
+// saveModuleHashes stores the module hashes in the database
+func saveModuleHashes(ctx context.Context, conn *sql.DB, hashes map[string]string) error {
+	value, err := json.Marshal(hashes)
+	if err != nil {
+		return fmt.Errorf("failed to encode module hashes: %w", err)
+	}
+	return db.SetConfigParameter(ctx, conn, moduleHashesParam, string(value))
+}
+
+// uniqueSorted returns the distinct values sorted
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
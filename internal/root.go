@@ -44,8 +44,27 @@ func init() {
 	rootCmd.AddCommand(commands.NewRestoredbCmd())
 	rootCmd.AddCommand(commands.NewCopydbCmd())
 	rootCmd.AddCommand(commands.NewInitDBCmd())
+	rootCmd.AddCommand(commands.NewTemplatesCmd())
 	rootCmd.AddCommand(commands.NewDropdbCmd())
 	rootCmd.AddCommand(commands.NewRenamedbCmd())
+	rootCmd.AddCommand(commands.NewDbCmd())
+	rootCmd.AddCommand(commands.NewSQLCmd())
+	rootCmd.AddCommand(commands.NewPsqlCmd())
 	rootCmd.AddCommand(commands.NewConfigAddonCmd())
+	rootCmd.AddCommand(commands.NewModulesCmd())
+	rootCmd.AddCommand(commands.NewDepsCmd())
+	rootCmd.AddCommand(commands.NewScaffoldCmd())
+	rootCmd.AddCommand(commands.NewIdeCmd())
+	rootCmd.AddCommand(commands.NewParamCmd())
+	rootCmd.AddCommand(commands.NewCronCmd())
+	rootCmd.AddCommand(commands.NewMailCmd())
+	rootCmd.AddCommand(commands.NewFilestoreCmd())
 	rootCmd.AddCommand(commands.NewStartOdooCmd())
+	rootCmd.AddCommand(commands.NewUpgradeCmd())
+	rootCmd.AddCommand(commands.NewInstallCmd())
+	rootCmd.AddCommand(commands.NewUninstallCmd())
+	rootCmd.AddCommand(commands.NewTestCmd())
+	rootCmd.AddCommand(commands.NewStopCmd())
+	rootCmd.AddCommand(commands.NewPsCmd())
+	rootCmd.AddCommand(commands.NewLogsCmd())
 }
@@ -0,0 +1,138 @@
+package addons
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestFile is the file that turns a directory into an Odoo module
+const ManifestFile = "__manifest__.py"
+
+// IsModule reports whether dir contains an Odoo module manifest
+func IsModule(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, ManifestFile))
+	return err == nil && !info.IsDir()
+}
+
+// ValidModuleName reports whether name is a valid technical module name
+func ValidModuleName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitPaths parses a comma-separated addons_path value
+func SplitPaths(addonsPath string) []string {
+	var paths []string
+	for _, p := range strings.Split(addonsPath, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// FindModule returns the directory of module name in the first addons
+// path that contains it, as Odoo resolves it.
+func FindModule(paths []string, name string) (string, bool) {
+	for _, p := range paths {
+		dir := filepath.Join(p, name)
+		if IsModule(dir) {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// ListModules maps every module found directly under the addons paths to
+// its directory. When a name appears twice, the first path wins.
+func ListModules(paths []string) (map[string]string, error) {
+	modules := make(map[string]string)
+	for _, p := range paths {
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read addons path %s: %w", p, err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			if _, seen := modules[entry.Name()]; seen {
+				continue
+			}
+			dir := filepath.Join(p, entry.Name())
+			if IsModule(dir) {
+				modules[entry.Name()] = dir
+			}
+		}
+	}
+	return modules, nil
+}
+
+// skipInHash reports files that never affect an upgrade
+func skipInHash(name string, isDir bool) bool {
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+	if isDir {
+		return name == "__pycache__"
+	}
+	ext := filepath.Ext(name)
+	return ext == ".pyc" || ext == ".pyo"
+}
+
+// HashModule returns a SHA-1 over the relative paths and contents of every
+// source file of the module in dir.
+func HashModule(dir string) (string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && skipInHash(d.Name(), d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	sort.Strings(files)
+
+	h := sha1.New()
+	for _, path := range files {
+		rel, _ := filepath.Rel(dir, path)
+		io.WriteString(h, filepath.ToSlash(rel))
+		h.Write([]byte{0})
+		f, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
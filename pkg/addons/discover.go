@@ -0,0 +1,207 @@
+package addons
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultMaxDepth bounds how deep Discover looks below each root
+const DefaultMaxDepth = 6
+
+// skipDirs are never scanned for modules
+var skipDirs = map[string]bool{
+	"__pycache__":  true,
+	"node_modules": true,
+	"setup":        true,
+	"static":       true,
+}
+
+// Module is an addon found on disk
+type Module struct {
+	Name       string
+	Dir        string
+	AddonsPath string
+	Manifest   *Manifest
+	// Err is set when the manifest could not be parsed
+	Err error
+}
+
+// Installable reports whether Odoo would consider the module
+func (m *Module) Installable() bool {
+	return m.Err == nil && m.Manifest != nil && m.Manifest.Installable
+}
+
+// Duplicate is a module name found in several addons paths
+type Duplicate struct {
+	Name     string
+	Winner   *Module
+	Shadowed []*Module
+}
+
+// Discovery is the result of scanning directories for modules
+type Discovery struct {
+	// Paths is the minimal addons_path: every directory providing at
+	// least one module that wins over its duplicates, in discovery order
+	Paths []string
+	// Modules maps each name to the module Odoo will load
+	Modules    map[string]*Module
+	Duplicates []*Duplicate
+	// Skipped are the modules declared not installable
+	Skipped []*Module
+	// Unparsed are the modules whose manifest could not be parsed; they
+	// are kept in the addons paths since Odoo may still load them
+	Unparsed []*Module
+}
+
+// Discover walks roots looking for module directories and computes the
+// addons_path that exposes all of their installable modules. Symlinked
+// directories are followed, each directory being scanned once. Roots are
+// scanned in order, so when a module appears twice the copy in the first
+// root wins, as it would in addons_path.
+func Discover(roots []string, maxDepth int) (*Discovery, error) {
+	d := &Discovery{Modules: make(map[string]*Module)}
+	pathIndex := make(map[string]int)
+	var candidates []string
+	var found []*Module
+
+	add := func(dir string) error {
+		mod := &Module{
+			Name:       filepath.Base(dir),
+			Dir:        dir,
+			AddonsPath: filepath.Dir(dir),
+		}
+		mod.Manifest, mod.Err = ReadManifest(dir)
+		if mod.Err != nil {
+			d.Unparsed = append(d.Unparsed, mod)
+		} else if !mod.Installable() {
+			d.Skipped = append(d.Skipped, mod)
+			return nil
+		}
+
+		if _, ok := pathIndex[mod.AddonsPath]; !ok {
+			pathIndex[mod.AddonsPath] = len(candidates)
+			candidates = append(candidates, mod.AddonsPath)
+		}
+		found = append(found, mod)
+		return nil
+	}
+
+	// Directories already scanned, by their resolved path, so that
+	// symlinked directories are followed without looping
+	visited := make(map[string]bool)
+	var walk func(dir string, depth int) error
+	walk = func(dir string, depth int) error {
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return err
+		}
+		if visited[real] {
+			return nil
+		}
+		visited[real] = true
+		if IsModule(dir) {
+			// Modules never contain other addons
+			return add(dir)
+		}
+		if depth >= maxDepth {
+			return nil
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if strings.HasPrefix(name, ".") || skipDirs[name] {
+				continue
+			}
+			path := filepath.Join(dir, name)
+			isDir := entry.IsDir()
+			if entry.Type()&fs.ModeSymlink != 0 {
+				// Broken links are ignored
+				info, err := os.Stat(path)
+				isDir = err == nil && info.IsDir()
+			}
+			if !isDir {
+				continue
+			}
+			if err := walk(path, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, root := range roots {
+		root, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(root); err != nil {
+			return nil, fmt.Errorf("cannot scan %s: %w", root, err)
+		}
+		if err := walk(root, 0); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+	}
+
+	// Odoo loads the copy of a module from the first directory of
+	// addons_path that has it, which is not always the first one visited
+	// when directories are nested
+	sort.SliceStable(found, func(i, j int) bool {
+		return pathIndex[found[i].AddonsPath] < pathIndex[found[j].AddonsPath]
+	})
+	dups := make(map[string]*Duplicate)
+	for _, mod := range found {
+		winner, ok := d.Modules[mod.Name]
+		if !ok {
+			d.Modules[mod.Name] = mod
+			continue
+		}
+		dup, ok := dups[mod.Name]
+		if !ok {
+			dup = &Duplicate{Name: mod.Name, Winner: winner}
+			dups[mod.Name] = dup
+			d.Duplicates = append(d.Duplicates, dup)
+		}
+		dup.Shadowed = append(dup.Shadowed, mod)
+	}
+
+	// Drop directories whose modules are all shadowed by earlier copies;
+	// the winners stay the same since each is in the first directory
+	// having its name
+	used := make(map[string]bool)
+	for _, mod := range d.Modules {
+		used[mod.AddonsPath] = true
+	}
+	for _, p := range candidates {
+		if used[p] {
+			d.Paths = append(d.Paths, p)
+		}
+	}
+	return d, nil
+}
+
+// LoadModules reads the manifest of every module directly under the
+// addons paths, sorted by name. Modules shadowed by an earlier path are
+// not included.
+func LoadModules(paths []string) ([]*Module, error) {
+	dirs, err := ListModules(paths)
+	if err != nil {
+		return nil, err
+	}
+	modules := make([]*Module, 0, len(dirs))
+	for name, dir := range dirs {
+		mod := &Module{Name: name, Dir: dir, AddonsPath: filepath.Dir(dir)}
+		mod.Manifest, mod.Err = ReadManifest(dir)
+		modules = append(modules, mod)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Name < modules[j].Name
+	})
+	return modules, nil
+}
@@ -0,0 +1,269 @@
+package addons
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Graph is the dependency graph of a set of modules
+type Graph struct {
+	// Deps maps each module to its direct dependencies
+	Deps map[string][]string
+	// Missing maps modules to the dependencies not found on disk
+	Missing map[string][]string
+	rdeps   map[string][]string
+}
+
+// NewGraph builds the graph of the installable modules
+func NewGraph(modules []*Module) *Graph {
+	g := &Graph{
+		Deps:    make(map[string][]string),
+		Missing: make(map[string][]string),
+		rdeps:   make(map[string][]string),
+	}
+	for _, mod := range modules {
+		if mod.Installable() {
+			g.Deps[mod.Name] = uniqueStrings(mod.Manifest.Depends)
+		}
+	}
+	for name, deps := range g.Deps {
+		for _, dep := range deps {
+			if _, ok := g.Deps[dep]; !ok {
+				g.Missing[name] = append(g.Missing[name], dep)
+				continue
+			}
+			g.rdeps[dep] = append(g.rdeps[dep], name)
+		}
+	}
+	for name := range g.rdeps {
+		sort.Strings(g.rdeps[name])
+	}
+	return g
+}
+
+// Has reports whether the module is part of the graph
+func (g *Graph) Has(name string) bool {
+	_, ok := g.Deps[name]
+	return ok
+}
+
+// Closure returns root and every module it transitively depends on,
+// leaving out missing dependencies
+func (g *Graph) Closure(root string) []string {
+	seen := map[string]bool{root: true}
+	stack := []string{root}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, dep := range g.Deps[current] {
+			if g.Has(dep) && !seen[dep] {
+				seen[dep] = true
+				stack = append(stack, dep)
+			}
+		}
+	}
+	nodes := make([]string, 0, len(seen))
+	for name := range seen {
+		nodes = append(nodes, name)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// ReverseDeps returns every module that transitively depends on name,
+// mapped to its distance from name
+func (g *Graph) ReverseDeps(name string) map[string]int {
+	depth := map[string]int{name: 0}
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, parent := range g.rdeps[current] {
+			if _, seen := depth[parent]; !seen {
+				depth[parent] = depth[current] + 1
+				queue = append(queue, parent)
+			}
+		}
+	}
+	delete(depth, name)
+	return depth
+}
+
+// Cycles returns the dependency cycles, each as a sorted list of modules
+func (g *Graph) Cycles() [][]string {
+	// Tarjan's strongly connected components
+	index := 0
+	indices := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+
+	var connect func(string)
+	connect = func(v string) {
+		indices[v] = index
+		lowlink[v] = index
+		index++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range g.Deps[v] {
+			if !g.Has(w) {
+				continue
+			}
+			if _, visited := indices[w]; !visited {
+				connect(w)
+				lowlink[v] = min(lowlink[v], lowlink[w])
+			} else if onStack[w] {
+				lowlink[v] = min(lowlink[v], indices[w])
+			}
+		}
+
+		if lowlink[v] == indices[v] {
+			var component []string
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component = append(component, w)
+				if w == v {
+					break
+				}
+			}
+			if len(component) > 1 || containsString(g.Deps[v], v) {
+				sort.Strings(component)
+				cycles = append(cycles, component)
+			}
+		}
+	}
+
+	for _, name := range g.Names() {
+		if _, visited := indices[name]; !visited {
+			connect(name)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// Names returns the modules of the graph, sorted
+func (g *Graph) Names() []string {
+	names := make([]string, 0, len(g.Deps))
+	for name := range g.Deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// edges returns the dependency edges among nodes, including edges to
+// missing dependencies
+func (g *Graph) edges(nodes []string) [][2]string {
+	var edges [][2]string
+	for _, name := range nodes {
+		for _, dep := range g.Deps[name] {
+			edges = append(edges, [2]string{name, dep})
+		}
+	}
+	return edges
+}
+
+// WriteDOT writes the subgraph of nodes in Graphviz DOT format
+func (g *Graph) WriteDOT(w io.Writer, nodes []string) error {
+	var sb strings.Builder
+	sb.WriteString("digraph modules {\n  rankdir=BT;\n  node [shape=box];\n")
+	for _, name := range nodes {
+		fmt.Fprintf(&sb, "  %q;\n", name)
+	}
+	for _, name := range g.missingAmong(nodes) {
+		fmt.Fprintf(&sb, "  %q [style=dashed, color=red];\n", name)
+	}
+	for _, e := range g.edges(nodes) {
+		fmt.Fprintf(&sb, "  %q -> %q;\n", e[0], e[1])
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteMermaid writes the subgraph of nodes as a Mermaid flowchart
+func (g *Graph) WriteMermaid(w io.Writer, nodes []string) error {
+	var sb strings.Builder
+	sb.WriteString("graph BT\n")
+	for _, name := range nodes {
+		fmt.Fprintf(&sb, "  %s[%s]\n", name, name)
+	}
+	for _, name := range g.missingAmong(nodes) {
+		fmt.Fprintf(&sb, "  %s[%s]:::missing\n", name, name)
+	}
+	for _, e := range g.edges(nodes) {
+		fmt.Fprintf(&sb, "  %s --> %s\n", e[0], e[1])
+	}
+	sb.WriteString("  classDef missing stroke:#f00,stroke-dasharray:5\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteJSON writes the subgraph of nodes with its cycles and missing
+// dependencies as JSON
+func (g *Graph) WriteJSON(w io.Writer, nodes []string) error {
+	type jsonGraph struct {
+		Modules map[string][]string `json:"modules"`
+		Missing map[string][]string `json:"missing"`
+		Cycles  [][]string          `json:"cycles"`
+	}
+	out := jsonGraph{
+		Modules: make(map[string][]string, len(nodes)),
+		Missing: make(map[string][]string),
+		Cycles:  [][]string{},
+	}
+	inNodes := make(map[string]bool, len(nodes))
+	for _, name := range nodes {
+		inNodes[name] = true
+		out.Modules[name] = append([]string{}, g.Deps[name]...)
+		if missing, ok := g.Missing[name]; ok {
+			out.Missing[name] = missing
+		}
+	}
+	for _, cycle := range g.Cycles() {
+		if inNodes[cycle[0]] {
+			out.Cycles = append(out.Cycles, cycle)
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func (g *Graph) missingAmong(nodes []string) []string {
+	var missing []string
+	for _, name := range nodes {
+		missing = append(missing, g.Missing[name]...)
+	}
+	return uniqueStrings(missing)
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
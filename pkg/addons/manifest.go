@@ -0,0 +1,368 @@
+package addons
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Manifest holds the keys of __manifest__.py that ocli understands
+type Manifest struct {
+	Name        string
+	Version     string
+	Summary     string
+	Author      string
+	License     string
+	Category    string
+	Depends     []string
+	Data        []string
+	Demo        []string
+	Installable bool
+	AutoInstall bool
+	Application bool
+	// ExternalDependencies maps "python"/"bin" to their requirements
+	ExternalDependencies map[string][]string
+	// Raw is the whole manifest dict as parsed
+	Raw map[string]interface{}
+}
+
+// ReadManifest parses the manifest of the module in dir
+func ReadManifest(dir string) (*Manifest, error) {
+	content, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest of %s: %w", dir, err)
+	}
+	manifest, err := ParseManifest(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest in %s: %w", dir, err)
+	}
+	return manifest, nil
+}
+
+// ParseManifest parses the Python dict literal of a manifest file
+func ParseManifest(content []byte) (*Manifest, error) {
+	p := &literalParser{src: []rune(string(content))}
+	p.skipSpace()
+	// Skip a leading module docstring, if any
+	if p.peek() == '"' || p.peek() == '\'' {
+		if _, err := p.parseString(); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("manifest is not a dict")
+	}
+
+	m := &Manifest{
+		Name:        stringValue(raw["name"]),
+		Version:     stringValue(raw["version"]),
+		Summary:     stringValue(raw["summary"]),
+		Author:      stringValue(raw["author"]),
+		License:     stringValue(raw["license"]),
+		Category:    stringValue(raw["category"]),
+		Depends:     stringList(raw["depends"]),
+		Data:        stringList(raw["data"]),
+		Demo:        stringList(raw["demo"]),
+		Installable: true,
+		Raw:         raw,
+	}
+	if v, ok := raw["installable"].(bool); ok {
+		m.Installable = v
+	}
+	switch v := raw["auto_install"].(type) {
+	case bool:
+		m.AutoInstall = v
+	case []interface{}:
+		// auto_install may list the dependencies that trigger it
+		m.AutoInstall = true
+	}
+	if v, ok := raw["application"].(bool); ok {
+		m.Application = v
+	}
+	if deps, ok := raw["external_dependencies"].(map[string]interface{}); ok {
+		m.ExternalDependencies = make(map[string][]string, len(deps))
+		for kind, list := range deps {
+			m.ExternalDependencies[kind] = stringList(list)
+		}
+	}
+	return m, nil
+}
+
+func stringValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
+
+func stringList(v interface{}) []string {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// literalParser parses the subset of Python literals used in manifests:
+// dicts, lists, tuples, strings, numbers, True, False and None
+type literalParser struct {
+	src []rune
+	pos int
+}
+
+func (p *literalParser) peek() rune {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *literalParser) errorf(format string, args ...interface{}) error {
+	line := 1 + strings.Count(string(p.src[:p.pos]), "\n")
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips whitespace, comments and line continuations
+func (p *literalParser) skipSpace() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\\':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *literalParser) parseValue() (interface{}, error) {
+	p.skipSpace()
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, p.errorf("unexpected end of manifest")
+	case c == '{':
+		return p.parseDict()
+	case c == '[':
+		return p.parseSequence('[', ']')
+	case c == '(':
+		return p.parseSequence('(', ')')
+	case c == '"' || c == '\'' || isStringPrefix(p.src[p.pos:]):
+		// Adjacent literals are concatenated, as in Python
+		var sb strings.Builder
+		for {
+			s, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			sb.WriteString(s)
+			p.skipSpace()
+			if c := p.peek(); c != '"' && c != '\'' && !isStringPrefix(p.src[p.pos:]) {
+				return sb.String(), nil
+			}
+		}
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	default:
+		return p.parseName()
+	}
+}
+
+func (p *literalParser) parseDict() (interface{}, error) {
+	p.pos++ // {
+	dict := make(map[string]interface{})
+	for {
+		p.skipSpace()
+		if p.peek() == '}' {
+			p.pos++
+			return dict, nil
+		}
+		key, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.peek() != ':' {
+			return nil, p.errorf("expected ':' after dict key")
+		}
+		p.pos++
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		dict[fmt.Sprint(key)] = value
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+		default:
+			return nil, p.errorf("expected ',' or '}' in dict")
+		}
+	}
+}
+
+func (p *literalParser) parseSequence(open, close rune) (interface{}, error) {
+	p.pos++ // opening bracket
+	list := []interface{}{}
+	for {
+		p.skipSpace()
+		if p.peek() == close {
+			p.pos++
+			return list, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case close:
+		default:
+			return nil, p.errorf("expected ',' or '%c' after %c", close, open)
+		}
+	}
+}
+
+// isStringPrefix reports whether s starts with a string prefix like r' or u"
+func isStringPrefix(s []rune) bool {
+	for i := 0; i < len(s) && i < 3; i++ {
+		switch s[i] {
+		case 'r', 'R', 'u', 'U', 'b', 'B', 'f', 'F':
+			continue
+		case '\'', '"':
+			return i > 0
+		}
+		return false
+	}
+	return false
+}
+
+func (p *literalParser) parseString() (string, error) {
+	raw := false
+	for p.peek() != '\'' && p.peek() != '"' {
+		if c := p.peek(); c == 'r' || c == 'R' {
+			raw = true
+		}
+		p.pos++
+	}
+	quote := p.src[p.pos]
+	triple := p.pos+2 < len(p.src) && p.src[p.pos+1] == quote && p.src[p.pos+2] == quote
+	if triple {
+		p.pos += 3
+	} else {
+		p.pos++
+	}
+
+	var sb strings.Builder
+	for {
+		if p.pos >= len(p.src) {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.src[p.pos]
+		if c == quote {
+			if !triple {
+				p.pos++
+				return sb.String(), nil
+			}
+			if p.pos+2 < len(p.src) && p.src[p.pos+1] == quote && p.src[p.pos+2] == quote {
+				p.pos += 3
+				return sb.String(), nil
+			}
+		}
+		if c == '\n' && !triple {
+			return "", p.errorf("unterminated string")
+		}
+		if c == '\\' && p.pos+1 < len(p.src) {
+			next := p.src[p.pos+1]
+			p.pos += 2
+			if raw {
+				sb.WriteRune(c)
+				sb.WriteRune(next)
+				continue
+			}
+			switch next {
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			case '\n':
+				// escaped newline: line continuation inside the string
+			default:
+				sb.WriteRune(next)
+			}
+			continue
+		}
+		sb.WriteRune(c)
+		p.pos++
+	}
+}
+
+func (p *literalParser) parseNumber() (interface{}, error) {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if (c >= '0' && c <= '9') || c == '.' || c == '-' || c == '+' || c == '_' ||
+			c == 'e' || c == 'E' || c == 'x' || c == 'X' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	text := strings.ReplaceAll(string(p.src[start:p.pos]), "_", "")
+	if n, err := strconv.ParseInt(text, 0, 64); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %q", text)
+	}
+	return f, nil
+}
+
+func (p *literalParser) parseName() (interface{}, error) {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	switch name := string(p.src[start:p.pos]); name {
+	case "True":
+		return true, nil
+	case "False":
+		return false, nil
+	case "None":
+		return nil, nil
+	case "":
+		return nil, p.errorf("unexpected character %q", p.peek())
+	default:
+		return nil, p.errorf("unsupported expression %q", name)
+	}
+}
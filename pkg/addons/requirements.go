@@ -0,0 +1,268 @@
+package addons
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RequirementsFile is the conventional name of pip requirement files
+const RequirementsFile = "requirements.txt"
+
+var (
+	requirementNameRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)`)
+	nameSeparatorRe   = regexp.MustCompile(`[-_.]+`)
+)
+
+// Requirement is a Python package requirement and where it came from
+type Requirement struct {
+	// Name is the normalized package name (PEP 503)
+	Name string
+	// Spec is the requirement line, e.g. "requests>=2.25"
+	Spec    string
+	Sources []string
+	parts   *requirementParts
+}
+
+// Pinned reports whether the requirement constrains the version, or uses
+// markers, extras or a direct reference
+func (r *Requirement) Pinned() bool {
+	return strings.TrimSpace(r.Spec) != strings.TrimSpace(requirementNameRe.FindString(r.Spec))
+}
+
+// RequirementConflict is a package required with specs that no version
+// satisfies together
+type RequirementConflict struct {
+	Name  string
+	Specs []*Requirement
+}
+
+// requirementGroup holds the requirements of a package under one
+// environment marker. Odoo's own requirements.txt lists packages several
+// times with exclusive markers, e.g. one pin per python_version.
+type requirementGroup struct {
+	name   string
+	marker string
+	reqs   []*Requirement
+}
+
+// constrained returns the requirements restricting the version
+func (g *requirementGroup) constrained() []*Requirement {
+	var reqs []*Requirement
+	for _, req := range g.reqs {
+		if req.parts.constrained() {
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs
+}
+
+// RequirementSet merges requirements by package name and marker
+type RequirementSet struct {
+	groups   map[string]*requirementGroup
+	order    []string
+	unparsed []string
+}
+
+// NewRequirementSet returns an empty set
+func NewRequirementSet() *RequirementSet {
+	return &RequirementSet{groups: make(map[string]*requirementGroup)}
+}
+
+// NormalizeName normalizes a Python package name as pip does
+func NormalizeName(name string) string {
+	return strings.ToLower(nameSeparatorRe.ReplaceAllString(name, "-"))
+}
+
+// specKey compares specs without whitespace, so "a >= 1" and "a>=1" match
+func specKey(spec string) string {
+	return strings.Join(strings.Fields(spec), "")
+}
+
+// Add records the requirement line spec coming from source
+func (s *RequirementSet) Add(spec, source string) {
+	spec = strings.TrimSpace(spec)
+	parts := splitRequirement(spec)
+	if parts.name == "" {
+		s.unparsed = append(s.unparsed, fmt.Sprintf("%s (%s)", spec, source))
+		return
+	}
+	name := NormalizeName(parts.name)
+	key := name + ";" + markerKey(parts.marker)
+
+	group, ok := s.groups[key]
+	if !ok {
+		group = &requirementGroup{name: name, marker: markerKey(parts.marker)}
+		s.groups[key] = group
+		s.order = append(s.order, key)
+	}
+	for _, req := range group.reqs {
+		if specKey(req.Spec) == specKey(spec) {
+			req.Sources = appendUnique(req.Sources, source)
+			return
+		}
+	}
+	group.reqs = append(group.reqs, &Requirement{Name: name, Spec: spec, Sources: []string{source}, parts: parts})
+}
+
+// sortedGroups returns the groups by package name, then marker
+func (s *RequirementSet) sortedGroups() []*requirementGroup {
+	groups := make([]*requirementGroup, 0, len(s.order))
+	for _, key := range s.order {
+		groups = append(groups, s.groups[key])
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].name != groups[j].name {
+			return groups[i].name < groups[j].name
+		}
+		return groups[i].marker < groups[j].marker
+	})
+	return groups
+}
+
+// Requirements returns the merged requirements sorted by name, one per
+// package and marker. When a package is required both bare and with a
+// constraint, the constraint wins; compatible constraints are combined
+// into one line, and of incompatible ones the first added is kept and the
+// package is reported by Conflicts.
+func (s *RequirementSet) Requirements() []*Requirement {
+	groups := s.sortedGroups()
+	merged := make([]*Requirement, 0, len(groups))
+	for _, group := range groups {
+		spec := group.reqs[0].Spec
+		credited := group.reqs
+		switch constrained := group.constrained(); {
+		case len(constrained) == 0:
+			for _, req := range group.reqs {
+				if req.parts.extras != "" {
+					spec = req.Spec
+					break
+				}
+			}
+		case len(constrained) == 1:
+			spec = constrained[0].Spec
+		case len(constrained) > 1 && compatible(constrained):
+			spec = combinedSpec(constrained)
+		case len(constrained) > 1:
+			// Credit the chosen spec and the bare requirements it satisfies
+			spec = constrained[0].Spec
+			credited = nil
+			for _, req := range group.reqs {
+				if req == constrained[0] || !req.parts.constrained() {
+					credited = append(credited, req)
+				}
+			}
+		}
+		var sources []string
+		for _, req := range credited {
+			for _, src := range req.Sources {
+				sources = appendUnique(sources, src)
+			}
+		}
+		merged = append(merged, &Requirement{Name: group.name, Spec: spec, Sources: sources, parts: splitRequirement(spec)})
+	}
+	return merged
+}
+
+// Conflicts returns the packages required under the same marker with
+// specs that cannot be satisfied together
+func (s *RequirementSet) Conflicts() []RequirementConflict {
+	var conflicts []RequirementConflict
+	for _, group := range s.sortedGroups() {
+		if constrained := group.constrained(); len(constrained) > 1 && !compatible(constrained) {
+			conflicts = append(conflicts, RequirementConflict{Name: group.name, Specs: constrained})
+		}
+	}
+	return conflicts
+}
+
+// Unparsed returns the lines that could not be read as requirements
+func (s *RequirementSet) Unparsed() []string {
+	return s.unparsed
+}
+
+// ReadRequirementsFile returns the requirement lines of a pip requirements
+// file, without comments, blank lines and pip options
+func ReadRequirementsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseRequirements(f)
+}
+
+func parseRequirements(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// FindRequirementsFiles returns the requirements.txt files of the addons
+// paths and of the git repositories that contain them
+func FindRequirementsFiles(paths []string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	check := func(dir string) {
+		file := filepath.Join(dir, RequirementsFile)
+		if seen[file] {
+			return
+		}
+		seen[file] = true
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			files = append(files, file)
+		}
+	}
+	for _, p := range paths {
+		check(p)
+		if root := repoRoot(p); root != "" {
+			check(root)
+		}
+	}
+	return files
+}
+
+// repoRoot returns the closest directory holding a .git entry at or a few
+// levels above dir
+func repoRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return ""
+}
+
+func appendUnique(values []string, v string) []string {
+	for _, existing := range values {
+		if existing == v {
+			return values
+		}
+	}
+	return append(values, v)
+}
@@ -0,0 +1,366 @@
+package addons
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	specClauseRe = regexp.MustCompile(`^(===|~=|==|!=|<=|>=|<|>)\s*([^\s,;]+)$`)
+	pyVersionRe  = regexp.MustCompile(`(?i)^v?(\d+(?:\.\d+)*)(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d*))?(?:[-_.]?(post|rev|r)[-_.]?(\d*))?(?:[-_.]?(dev)[-_.]?(\d*))?(?:\+[a-z0-9.]+)?$`)
+)
+
+// requirementParts are the pieces of a requirement line, e.g.
+// name[extras] >=1.0,<2 ; python_version < '3.12'
+type requirementParts struct {
+	name    string
+	extras  string
+	clauses []specClause
+	url     string
+	marker  string
+	// opaque is set when the specifier could not be parsed
+	opaque bool
+}
+
+// specClause is one comparison of a version specifier, e.g. >=2.25
+type specClause struct {
+	op      string
+	version string
+}
+
+func (c specClause) String() string {
+	return c.op + c.version
+}
+
+// splitRequirement parses a requirement line as pip does, without
+// evaluating its marker
+func splitRequirement(spec string) *requirementParts {
+	parts := &requirementParts{}
+	if i := strings.Index(spec, ";"); i >= 0 {
+		parts.marker = strings.TrimSpace(spec[i+1:])
+		spec = spec[:i]
+	}
+	parts.name = requirementNameRe.FindString(spec)
+	rest := strings.TrimSpace(spec[len(parts.name):])
+	if strings.HasPrefix(rest, "[") {
+		if end := strings.Index(rest, "]"); end >= 0 {
+			parts.extras = rest[:end+1]
+			rest = strings.TrimSpace(rest[end+1:])
+		}
+	}
+	if strings.HasPrefix(rest, "@") {
+		parts.url = strings.TrimSpace(rest[1:])
+		return parts
+	}
+	rest = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(rest, "("), ")"))
+	if rest == "" {
+		return parts
+	}
+	for _, clause := range strings.Split(rest, ",") {
+		m := specClauseRe.FindStringSubmatch(strings.TrimSpace(clause))
+		if m == nil {
+			parts.opaque = true
+			continue
+		}
+		parts.clauses = append(parts.clauses, specClause{op: m[1], version: m[2]})
+	}
+	return parts
+}
+
+// constrained reports whether the requirement restricts what pip installs
+func (p *requirementParts) constrained() bool {
+	return len(p.clauses) > 0 || p.url != "" || p.opaque
+}
+
+// markerKey normalizes a marker so that spacing and quoting differences
+// do not separate requirements
+func markerKey(marker string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(marker), ""), `"`, "'")
+}
+
+// pyVersion is a PEP 440 version reduced to what ordering needs
+type pyVersion struct {
+	release []int
+	// phase orders dev releases before pre-releases before final
+	// releases: -4 dev, -3 alpha, -2 beta, -1 rc, 0 final
+	phase int
+	pre   int
+	post  int
+	dev   int
+}
+
+func parsePyVersion(s string) (pyVersion, bool) {
+	m := pyVersionRe.FindStringSubmatch(s)
+	if m == nil {
+		return pyVersion{}, false
+	}
+	v := pyVersion{post: -1, dev: math.MaxInt}
+	for _, part := range strings.Split(m[1], ".") {
+		n, _ := strconv.Atoi(part)
+		v.release = append(v.release, n)
+	}
+	switch strings.ToLower(m[2]) {
+	case "a", "alpha":
+		v.phase = -3
+	case "b", "beta":
+		v.phase = -2
+	case "c", "rc", "pre", "preview":
+		v.phase = -1
+	}
+	v.pre, _ = strconv.Atoi(m[3])
+	if m[4] != "" {
+		v.post, _ = strconv.Atoi(m[5])
+	}
+	if m[6] != "" {
+		v.dev, _ = strconv.Atoi(m[7])
+		if m[2] == "" && m[4] == "" {
+			v.phase = -4
+		}
+	}
+	return v, true
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func comparePyVersions(a, b pyVersion) int {
+	for i := 0; i < len(a.release) || i < len(b.release); i++ {
+		x, y := 0, 0
+		if i < len(a.release) {
+			x = a.release[i]
+		}
+		if i < len(b.release) {
+			y = b.release[i]
+		}
+		if c := compareInts(x, y); c != 0 {
+			return c
+		}
+	}
+	for _, c := range []int{
+		compareInts(a.phase, b.phase),
+		compareInts(a.pre, b.pre),
+		compareInts(a.post, b.post),
+		compareInts(a.dev, b.dev),
+	} {
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// bumpRelease returns the first release after every version starting
+// with prefix, e.g. 2.0 for the prefix 1
+func bumpRelease(prefix []int) pyVersion {
+	next := append([]int{}, prefix...)
+	next[len(next)-1]++
+	return pyVersion{release: next, phase: -4, post: -1, dev: 0}
+}
+
+// versionBound is one end of a version range
+type versionBound struct {
+	version   pyVersion
+	inclusive bool
+	set       bool
+}
+
+// versionRange is the set of versions allowed by specifier clauses
+type versionRange struct {
+	lower, upper versionBound
+	pins         []pyVersion
+	excluded     []pyVersion
+}
+
+// raise moves the lower bound up to v, reporting whether it moved
+func (r *versionRange) raise(v pyVersion, inclusive bool) bool {
+	if r.lower.set {
+		c := comparePyVersions(v, r.lower.version)
+		if c < 0 || (c == 0 && (inclusive || !r.lower.inclusive)) {
+			return false
+		}
+	}
+	r.lower = versionBound{v, inclusive, true}
+	return true
+}
+
+// cap moves the upper bound down to v, reporting whether it moved
+func (r *versionRange) cap(v pyVersion, inclusive bool) bool {
+	if r.upper.set {
+		c := comparePyVersions(v, r.upper.version)
+		if c > 0 || (c == 0 && (inclusive || !r.upper.inclusive)) {
+			return false
+		}
+	}
+	r.upper = versionBound{v, inclusive, true}
+	return true
+}
+
+// add narrows the range by a clause, failing on versions it cannot read
+func (r *versionRange) add(c specClause) bool {
+	if c.op == "==" && strings.HasSuffix(c.version, ".*") {
+		v, ok := parsePyVersion(strings.TrimSuffix(c.version, ".*"))
+		if !ok {
+			return false
+		}
+		r.raise(pyVersion{release: v.release, phase: -4, post: -1, dev: 0}, true)
+		r.cap(bumpRelease(v.release), false)
+		return true
+	}
+	if c.op == "!=" && strings.HasSuffix(c.version, ".*") {
+		// Excluding a whole series rarely empties a range, ignore it
+		return true
+	}
+	v, ok := parsePyVersion(c.version)
+	if !ok {
+		return false
+	}
+	switch c.op {
+	case "==", "===":
+		r.pins = append(r.pins, v)
+	case "!=":
+		r.excluded = append(r.excluded, v)
+	case ">=":
+		r.raise(v, true)
+	case ">":
+		r.raise(v, false)
+	case "<=":
+		r.cap(v, true)
+	case "<":
+		r.cap(v, false)
+	case "~=":
+		if len(v.release) < 2 {
+			return false
+		}
+		r.raise(v, true)
+		r.cap(bumpRelease(v.release[:len(v.release)-1]), false)
+	}
+	return true
+}
+
+// allows reports whether v is within the bounds and not excluded
+func (r *versionRange) allows(v pyVersion) bool {
+	if r.lower.set {
+		c := comparePyVersions(v, r.lower.version)
+		if c < 0 || (c == 0 && !r.lower.inclusive) {
+			return false
+		}
+	}
+	if r.upper.set {
+		c := comparePyVersions(v, r.upper.version)
+		if c > 0 || (c == 0 && !r.upper.inclusive) {
+			return false
+		}
+	}
+	for _, x := range r.excluded {
+		if comparePyVersions(v, x) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// empty reports whether no version satisfies the range
+func (r *versionRange) empty() bool {
+	if len(r.pins) > 0 {
+		for _, pin := range r.pins[1:] {
+			if comparePyVersions(pin, r.pins[0]) != 0 {
+				return true
+			}
+		}
+		return !r.allows(r.pins[0])
+	}
+	if !r.lower.set || !r.upper.set {
+		return false
+	}
+	c := comparePyVersions(r.lower.version, r.upper.version)
+	if c < 0 {
+		return false
+	}
+	if c > 0 || !r.lower.inclusive || !r.upper.inclusive {
+		return true
+	}
+	return !r.allows(r.lower.version)
+}
+
+// compatible reports whether a single version can satisfy all of the
+// requirements. Direct references and specifiers that cannot be parsed
+// are only compatible with identical ones.
+func compatible(reqs []*Requirement) bool {
+	for _, req := range reqs {
+		if req.parts.url != "" || req.parts.opaque {
+			for _, other := range reqs {
+				if specKey(other.Spec) != specKey(req.Spec) {
+					return false
+				}
+			}
+		}
+	}
+	var r versionRange
+	for _, req := range reqs {
+		for _, c := range req.parts.clauses {
+			if !r.add(c) {
+				return false
+			}
+		}
+	}
+	return !r.empty()
+}
+
+// combinedSpec writes compatible requirements as one line: a pin when
+// there is one, otherwise the tightest bounds and the other clauses
+func combinedSpec(reqs []*Requirement) string {
+	first := reqs[0].parts
+	if first.url != "" || first.opaque {
+		return reqs[0].Spec
+	}
+	var pin, lower, upper *specClause
+	var r versionRange
+	var others []string
+	for _, req := range reqs {
+		for _, c := range req.parts.clauses {
+			c := c
+			v, _ := parsePyVersion(c.version)
+			switch {
+			case (c.op == "==" || c.op == "===") && !strings.HasSuffix(c.version, ".*"):
+				pin = &c
+			case c.op == ">=" || c.op == ">":
+				if r.raise(v, c.op == ">=") {
+					lower = &c
+				}
+			case c.op == "<=" || c.op == "<":
+				if r.cap(v, c.op == "<=") {
+					upper = &c
+				}
+			default:
+				others = appendUnique(others, c.String())
+			}
+		}
+	}
+
+	var clauses []string
+	if pin != nil {
+		clauses = []string{pin.String()}
+	} else {
+		if lower != nil {
+			clauses = append(clauses, lower.String())
+		}
+		if upper != nil {
+			clauses = append(clauses, upper.String())
+		}
+		clauses = append(clauses, others...)
+	}
+	spec := first.name + first.extras + strings.Join(clauses, ",")
+	if first.marker != "" {
+		spec += " ; " + first.marker
+	}
+	return spec
+}
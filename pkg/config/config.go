@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -12,8 +13,11 @@ import (
 )
 
 type Config struct {
-	Odoo OdooConfig `mapstructure:"odoo"`
-	DB   DBSection  `mapstructure:"db"`
+	Odoo      OdooConfig                `mapstructure:"odoo"`
+	DB        DBSection                 `mapstructure:"db"`
+	Ports     PortRange                 `mapstructure:"ports"`
+	Instances map[string]InstanceConfig `mapstructure:"instances"`
+	Scaffold  ScaffoldConfig            `mapstructure:"scaffold"`
 }
 
 type OdooConfig struct {
@@ -27,6 +31,31 @@ type DBSection struct {
 	DumpFormat string `mapstructure:"dump_format"`
 }
 
+// PortRange is the range used to auto-assign instance ports
+type PortRange struct {
+	From int `mapstructure:"from"`
+	To   int `mapstructure:"to"`
+}
+
+// InstanceConfig overrides the odoo section for a named instance
+type InstanceConfig struct {
+	ConfigFile string `mapstructure:"config_file"`
+	OdooBin    string `mapstructure:"odoo_bin"`
+	Database   string `mapstructure:"database"`
+	HTTPPort   int    `mapstructure:"http_port"`
+	GeventPort int    `mapstructure:"gevent_port"`
+	DataDir    string `mapstructure:"data_dir"`
+}
+
+// ScaffoldConfig define los valores por defecto de los módulos generados
+// y las plantillas propias del equipo (nombre -> directorio)
+type ScaffoldConfig struct {
+	Author    string            `mapstructure:"author"`
+	License   string            `mapstructure:"license"`
+	Website   string            `mapstructure:"website"`
+	Templates map[string]string `mapstructure:"templates"`
+}
+
 type DBConfig struct {
 	Host     string
 	Port     int
@@ -36,6 +65,15 @@ type DBConfig struct {
 
 var AppConfig Config
 
+// StateDir es el directorio donde ocli guarda el estado de las instancias
+const StateDir = ".ocli"
+
+// Rango de puertos por defecto para instancias
+const (
+	DefaultPortFrom = 8069
+	DefaultPortTo   = 8199
+)
+
 func LoadConfig() {
 	// Primero verificar si el archivo de configuración existe
 	configFile := "ocli.yml"
@@ -51,6 +89,7 @@ func LoadConfig() {
 				DumpPath:   "/workspace/dbs",
 				DumpFormat: "zip",
 			},
+			Ports: PortRange{From: DefaultPortFrom, To: DefaultPortTo},
 		}
 		return
 	}
@@ -62,18 +101,20 @@ func LoadConfig() {
 	if err := viper.ReadInConfig(); err != nil {
 		panic(fmt.Errorf("error leyendo archivo de configuración: %w", err))
 	}
+	viper.SetDefault("ports.from", DefaultPortFrom)
+	viper.SetDefault("ports.to", DefaultPortTo)
 	viper.Unmarshal(&AppConfig)
 }
 
-// LoadOdooDBParams extrae los parámetros de BD del archivo de configuración
-func LoadOdooDBParams(configPath string) (*DBConfig, error) {
+// ReadOdooConf lee todas las opciones clave-valor de un archivo odoo.conf
+func ReadOdooConf(configPath string) (map[string]string, error) {
 	file, err := os.Open(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("error abriendo %s: %w", configPath, err)
 	}
 	defer file.Close()
 
-	dbConfig := &DBConfig{}
+	options := make(map[string]string)
 	scanner := bufio.NewScanner(file)
 
 	for scanner.Scan() {
@@ -89,23 +130,7 @@ func LoadOdooDBParams(configPath string) (*DBConfig, error) {
 
 		// Procesar clave-valor
 		if parts := strings.SplitN(line, "=", 2); len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-
-			switch key {
-			case "db_host":
-				dbConfig.Host = value
-			case "db_port":
-				port, err := strconv.Atoi(value)
-				if err != nil {
-					return nil, fmt.Errorf("error convirtiendo db_port a entero: %w", err)
-				}
-				dbConfig.Port = port
-			case "db_user":
-				dbConfig.User = value
-			case "db_password":
-				dbConfig.Password = value
-			}
+			options[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
 		}
 	}
 
@@ -113,6 +138,41 @@ func LoadOdooDBParams(configPath string) (*DBConfig, error) {
 		return nil, fmt.Errorf("error leyendo archivo: %w", err)
 	}
 
+	return options, nil
+}
+
+// OdooDataDir devuelve el data_dir de odoo.conf o el valor por defecto de Odoo
+func OdooDataDir(options map[string]string) string {
+	if dir := options["data_dir"]; dir != "" && dir != "False" && dir != "None" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".local", "share", "Odoo")
+	}
+	return filepath.Join(home, ".local", "share", "Odoo")
+}
+
+// LoadOdooDBParams extrae los parámetros de BD del archivo de configuración
+func LoadOdooDBParams(configPath string) (*DBConfig, error) {
+	options, err := ReadOdooConf(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dbConfig := &DBConfig{
+		Host:     options["db_host"],
+		User:     options["db_user"],
+		Password: options["db_password"],
+	}
+	if value, ok := options["db_port"]; ok {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("error convirtiendo db_port a entero: %w", err)
+		}
+		dbConfig.Port = port
+	}
+
 	// Validar que todos los parámetros necesarios existan
 	if dbConfig.Host == "" || dbConfig.Port == 0 ||
 		dbConfig.User == "" || dbConfig.Password == "" {
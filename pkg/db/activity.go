@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Activity is a session of pg_stat_activity
+type Activity struct {
+	PID         int64
+	Database    string
+	User        string
+	Application string
+	ClientAddr  string
+	State       string
+	WaitEvent   string
+	Query       string
+	// Ages are measured by the server, zero when not applicable
+	BackendAge time.Duration
+	XactAge    time.Duration
+	QueryAge   time.Duration
+	StateAge   time.Duration
+	// BlockedBy are the sessions holding the locks this one waits for
+	BlockedBy []int64
+}
+
+// Idle tells whether the session is waiting for its client, inside a
+// transaction or not
+func (a Activity) Idle() bool {
+	return a.State == "idle" || a.State == "idle in transaction" || a.State == "idle in transaction (aborted)"
+}
+
+// ListActivity returns the client sessions connected to dbname, or to
+// every database when dbname is empty, except the current one
+func ListActivity(ctx context.Context, db *sql.DB, dbname string) ([]Activity, error) {
+	query := `
+		SELECT pid, COALESCE(datname, ''), COALESCE(usename, ''), COALESCE(application_name, ''),
+			COALESCE(host(client_addr), 'local'), COALESCE(state, ''),
+			COALESCE(wait_event_type || ':' || wait_event, ''), COALESCE(query, ''),
+			EXTRACT(EPOCH FROM now() - backend_start),
+			EXTRACT(EPOCH FROM now() - xact_start),
+			EXTRACT(EPOCH FROM now() - query_start),
+			EXTRACT(EPOCH FROM now() - state_change),
+			pg_blocking_pids(pid)
+		FROM pg_stat_activity
+		WHERE pid <> pg_backend_pid() AND backend_type = 'client backend'
+			AND ($1 = '' OR datname = $1)
+		ORDER BY datname, backend_start
+	`
+
+	rows, err := db.QueryContext(ctx, query, dbname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query activity: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []Activity
+	for rows.Next() {
+		var a Activity
+		var backend, xact, queryAge, state sql.NullFloat64
+		if err := rows.Scan(&a.PID, &a.Database, &a.User, &a.Application, &a.ClientAddr, &a.State,
+			&a.WaitEvent, &a.Query, &backend, &xact, &queryAge, &state, pq.Array(&a.BlockedBy)); err != nil {
+			return nil, fmt.Errorf("failed to scan activity: %w", err)
+		}
+		a.BackendAge = seconds(backend)
+		a.XactAge = seconds(xact)
+		a.QueryAge = seconds(queryAge)
+		a.StateAge = seconds(state)
+		sessions = append(sessions, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating activity: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func seconds(v sql.NullFloat64) time.Duration {
+	if !v.Valid {
+		return 0
+	}
+	return time.Duration(v.Float64 * float64(time.Second))
+}
+
+// TerminateBackends terminates the given sessions and returns the ones
+// that were still there to be terminated
+func TerminateBackends(ctx context.Context, db *sql.DB, pids []int64) ([]int64, error) {
+	query := "SELECT pid FROM unnest($1::int[]) AS pid WHERE pg_terminate_backend(pid)"
+	rows, err := db.QueryContext(ctx, query, pq.Array(pids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to terminate sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var terminated []int64
+	for rows.Next() {
+		var pid int64
+		if err := rows.Scan(&pid); err != nil {
+			return nil, fmt.Errorf("failed to scan pid: %w", err)
+		}
+		terminated = append(terminated, pid)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pids: %w", err)
+	}
+
+	return terminated, nil
+}
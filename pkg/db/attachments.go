@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// StoredAttachment is an ir_attachment kept in the filestore
+type StoredAttachment struct {
+	ID         int64
+	Name       string
+	ResModel   string
+	StoreFname string
+	Checksum   string
+	FileSize   int64
+}
+
+// storedAttachmentsQuery selects the attachments of the filestore; the
+// placeholder takes additional conditions
+const storedAttachmentsQuery = `
+	SELECT id, COALESCE(name, ''), COALESCE(res_model, ''), store_fname,
+		COALESCE(checksum, ''), COALESCE(file_size, 0)
+	FROM ir_attachment
+	WHERE store_fname IS NOT NULL AND store_fname <> '' %s
+	ORDER BY id
+`
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func listStoredAttachments(ctx context.Context, q queryer, query string, args ...interface{}) ([]StoredAttachment, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []StoredAttachment
+	for rows.Next() {
+		var a StoredAttachment
+		if err := rows.Scan(&a.ID, &a.Name, &a.ResModel, &a.StoreFname, &a.Checksum, &a.FileSize); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// ListStoredAttachments returns every attachment, whatever its res_field
+// or company, whose content is in the filestore
+func ListStoredAttachments(ctx context.Context, db *sql.DB) ([]StoredAttachment, error) {
+	return listStoredAttachments(ctx, db, fmt.Sprintf(storedAttachmentsQuery, ""))
+}
+
+// WithAttachmentsLocked runs fn with the attachments of the filestore
+// while ir_attachment is locked in SHARE mode, as Odoo does during its
+// filestore garbage collection, so no attachment can be created meanwhile
+func WithAttachmentsLocked(ctx context.Context, db *sql.DB, fn func([]StoredAttachment) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "LOCK ir_attachment IN SHARE MODE"); err != nil {
+		return fmt.Errorf("failed to lock ir_attachment: %w", err)
+	}
+	attachments, err := listStoredAttachments(ctx, tx, fmt.Sprintf(storedAttachmentsQuery, ""))
+	if err != nil {
+		return err
+	}
+	if err := fn(attachments); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AttachmentContent is the binary content of an attachment
+type AttachmentContent struct {
+	ID    int64
+	Datas []byte
+}
+
+// FileMove records an attachment content written to the filestore
+type FileMove struct {
+	ID         int64
+	StoreFname string
+	Checksum   string
+}
+
+// CountAttachmentsByStorage counts the attachments whose content is in
+// the database and in the filestore
+func CountAttachmentsByStorage(ctx context.Context, db *sql.DB) (inDB, inFile int64, err error) {
+	query := `
+		SELECT count(*) FILTER (WHERE db_datas IS NOT NULL),
+			count(*) FILTER (WHERE store_fname IS NOT NULL AND store_fname <> '')
+		FROM ir_attachment
+	`
+	if err := db.QueryRowContext(ctx, query).Scan(&inDB, &inFile); err != nil {
+		return 0, 0, fmt.Errorf("failed to count attachments: %w", err)
+	}
+	return inDB, inFile, nil
+}
+
+// ListDBAttachments returns up to limit attachments stored in the
+// database with an id greater than afterID, with their content
+func ListDBAttachments(ctx context.Context, db *sql.DB, afterID int64, limit int) ([]AttachmentContent, error) {
+	query := `
+		SELECT id, db_datas FROM ir_attachment
+		WHERE db_datas IS NOT NULL AND id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+	rows, err := db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var contents []AttachmentContent
+	for rows.Next() {
+		var c AttachmentContent
+		if err := rows.Scan(&c.ID, &c.Datas); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		contents = append(contents, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating attachments: %w", err)
+	}
+
+	return contents, nil
+}
+
+// ListFileAttachments returns up to limit attachments stored in the
+// filestore with an id greater than afterID
+func ListFileAttachments(ctx context.Context, db *sql.DB, afterID int64, limit int) ([]StoredAttachment, error) {
+	query := fmt.Sprintf(storedAttachmentsQuery, "AND id > $1") + " LIMIT $2"
+	return listStoredAttachments(ctx, db, query, afterID, limit)
+}
+
+// MoveAttachmentsToFile points the attachments at their filestore files
+// and clears their database content, in one transaction
+func MoveAttachmentsToFile(ctx context.Context, db *sql.DB, moves []FileMove) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE ir_attachment SET store_fname = $2, checksum = $3, db_datas = NULL
+		WHERE id = $1 AND db_datas IS NOT NULL
+	`
+	for _, m := range moves {
+		if _, err := tx.ExecContext(ctx, query, m.ID, m.StoreFname, m.Checksum); err != nil {
+			return fmt.Errorf("failed to update attachment %d: %w", m.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// MoveAttachmentsToDB stores the content in the attachments and clears
+// their filestore reference, in one transaction
+func MoveAttachmentsToDB(ctx context.Context, db *sql.DB, contents []AttachmentContent) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE ir_attachment SET db_datas = $2, store_fname = NULL
+		WHERE id = $1 AND store_fname IS NOT NULL
+	`
+	for _, c := range contents {
+		if _, err := tx.ExecContext(ctx, query, c.ID, c.Datas); err != nil {
+			return fmt.Errorf("failed to update attachment %d: %w", c.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Cron is a scheduled action of ir_cron
+type Cron struct {
+	ID             int64
+	Name           string
+	Model          string
+	Code           string
+	IntervalNumber int
+	IntervalType   string
+	NextCall       time.Time
+	LastCall       *time.Time
+	Active         bool
+	// FailureCount and FirstFailure are only tracked since Odoo 18
+	FailureCount int
+	FirstFailure *time.Time
+}
+
+// tableColumns returns the set of columns of a table
+func tableColumns(ctx context.Context, db *sql.DB, table string) (map[string]bool, error) {
+	query := `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+	`
+	rows, err := db.QueryContext(ctx, query, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan column name: %w", err)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// ListCrons returns every scheduled action, active or not, sorted by id.
+// Columns added in later Odoo versions are read only when present.
+func ListCrons(ctx context.Context, db *sql.DB) ([]Cron, error) {
+	columns, err := tableColumns(ctx, db, "ir_cron")
+	if err != nil {
+		return nil, err
+	}
+	lastCall := "NULL::timestamp"
+	if columns["lastcall"] {
+		lastCall = "c.lastcall"
+	}
+	failures := "0, NULL::timestamp"
+	if columns["failure_count"] && columns["first_failure_date"] {
+		failures = "c.failure_count, c.first_failure_date"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT c.id, COALESCE(c.cron_name, ''), COALESCE(s.model_name, ''), COALESCE(s.code, ''),
+			c.interval_number, c.interval_type, c.nextcall, %s, c.active, %s
+		FROM ir_cron c
+		JOIN ir_act_server s ON s.id = c.ir_actions_server_id
+		ORDER BY c.id
+	`, lastCall, failures)
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query crons: %w", err)
+	}
+	defer rows.Close()
+
+	var crons []Cron
+	for rows.Next() {
+		var c Cron
+		var last, firstFailure sql.NullTime
+		var failureCount sql.NullInt64
+		if err := rows.Scan(&c.ID, &c.Name, &c.Model, &c.Code, &c.IntervalNumber, &c.IntervalType,
+			&c.NextCall, &last, &c.Active, &failureCount, &firstFailure); err != nil {
+			return nil, fmt.Errorf("failed to scan cron: %w", err)
+		}
+		if last.Valid {
+			c.LastCall = &last.Time
+		}
+		if firstFailure.Valid {
+			c.FirstFailure = &firstFailure.Time
+		}
+		c.FailureCount = int(failureCount.Int64)
+		crons = append(crons, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating crons: %w", err)
+	}
+
+	return crons, nil
+}
+
+// SetCronsActive activates or deactivates the given crons and returns how
+// many changed
+func SetCronsActive(ctx context.Context, db *sql.DB, ids []int64, active bool) (int64, error) {
+	query := `
+		UPDATE ir_cron SET active = $1, write_date = NOW(), write_uid = 1
+		WHERE id = ANY($2) AND active <> $1
+	`
+	res, err := db.ExecContext(ctx, query, active, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to update crons: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
@@ -0,0 +1,253 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RedirectMailServers makes the outgoing mail server named name, pointing
+// at host:port without encryption nor authentication, the only active one.
+// It returns how many other servers were deactivated.
+func RedirectMailServers(ctx context.Context, db *sql.DB, name, host string, port int) (int64, error) {
+	columns, err := tableColumns(ctx, db, "ir_mail_server")
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE ir_mail_server SET active = false, write_date = NOW(), write_uid = 1
+		WHERE active AND name <> $1
+	`, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to deactivate mail servers: %w", err)
+	}
+	disabled, _ := res.RowsAffected()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, "SELECT id FROM ir_mail_server WHERE name = $1 ORDER BY id LIMIT 1", name).Scan(&id)
+	if err == sql.ErrNoRows {
+		insertColumns := "name, smtp_host, smtp_port, smtp_encryption, sequence, active"
+		insertValues := "$1, $2, $3, 'none', 1, true"
+		// smtp_authentication is required since Odoo 15
+		if columns["smtp_authentication"] {
+			insertColumns += ", smtp_authentication"
+			insertValues += ", 'login'"
+		}
+		err = tx.QueryRowContext(ctx, fmt.Sprintf(`
+			INSERT INTO ir_mail_server (%s, create_uid, create_date, write_uid, write_date)
+			VALUES (%s, 1, NOW(), 1, NOW())
+			RETURNING id
+		`, insertColumns, insertValues), name, host, port).Scan(&id)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to create mail server: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE ir_mail_server SET smtp_host = $2, smtp_port = $3, smtp_encryption = 'none',
+			smtp_user = NULL, smtp_pass = NULL, sequence = 1, active = true,
+			write_date = NOW(), write_uid = 1
+		WHERE id = $1
+	`, id, host, port)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update mail server: %w", err)
+	}
+
+	// Columns added in Odoo 15: accept any sender without login
+	if columns["smtp_authentication"] {
+		if _, err := tx.ExecContext(ctx, "UPDATE ir_mail_server SET smtp_authentication = 'login' WHERE id = $1", id); err != nil {
+			return 0, fmt.Errorf("failed to update mail server: %w", err)
+		}
+	}
+	if columns["from_filter"] {
+		if _, err := tx.ExecContext(ctx, "UPDATE ir_mail_server SET from_filter = NULL WHERE id = $1", id); err != nil {
+			return 0, fmt.Errorf("failed to update mail server: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return disabled, nil
+}
+
+// MailStates are the states of mail_mail shown by default
+var MailStates = []string{"outgoing", "exception", "sent"}
+
+// QueuedMail is an outgoing email of mail_mail
+type QueuedMail struct {
+	ID            int64
+	State         string
+	Recipients    string
+	Subject       string
+	Model         string
+	FailureReason string
+	CreateDate    time.Time
+}
+
+// MailFilter selects mail_mail records; empty fields match everything
+type MailFilter struct {
+	States []string
+	IDs    []int64
+	// Model is the model of the record the mail was sent from
+	Model string
+	// Failure and Recipient match as case-insensitive substrings
+	Failure   string
+	Recipient string
+	// Since and Before bound the creation date, in UTC
+	Since  time.Time
+	Before time.Time
+}
+
+// mailRecipients is the email_to of a mail_mail plus the emails of its
+// partner recipients
+const mailRecipients = `concat_ws(', ', NULLIF(m.email_to, ''), (
+	SELECT string_agg(p.email, ', ' ORDER BY p.id)
+	FROM mail_mail_res_partner_rel r JOIN res_partner p ON p.id = r.res_partner_id
+	WHERE r.mail_mail_id = m.id
+))`
+
+// where renders the filter as a condition on mail_mail m joined with
+// mail_message msg, with its arguments
+func (f MailFilter) where() (string, []interface{}) {
+	conds := []string{"true"}
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if len(f.States) > 0 {
+		add("m.state = ANY($%d)", pq.Array(f.States))
+	}
+	if len(f.IDs) > 0 {
+		add("m.id = ANY($%d)", pq.Array(f.IDs))
+	}
+	if f.Model != "" {
+		add("msg.model = $%d", f.Model)
+	}
+	if f.Failure != "" {
+		add("m.failure_reason ILIKE '%%' || $%d || '%%'", f.Failure)
+	}
+	if f.Recipient != "" {
+		add(mailRecipients+" ILIKE '%%' || $%d || '%%'", f.Recipient)
+	}
+	if !f.Since.IsZero() {
+		add("m.create_date >= $%d", f.Since.UTC().Format(time.DateTime))
+	}
+	if !f.Before.IsZero() {
+		add("m.create_date < $%d", f.Before.UTC().Format(time.DateTime))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// CountMailByState counts the mail_mail records matching filter per state
+func CountMailByState(ctx context.Context, db *sql.DB, filter MailFilter) (map[string]int64, error) {
+	where, args := filter.where()
+	query := `
+		SELECT m.state, count(*) FROM mail_mail m
+		JOIN mail_message msg ON msg.id = m.mail_message_id
+		WHERE ` + where + `
+		GROUP BY m.state
+	`
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count mail: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var state string
+		var count int64
+		if err := rows.Scan(&state, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan mail count: %w", err)
+		}
+		counts[state] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating mail counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// ListQueuedMail returns the newest mail_mail records matching filter,
+// at most limit of them when limit is positive
+func ListQueuedMail(ctx context.Context, db *sql.DB, filter MailFilter, limit int) ([]QueuedMail, error) {
+	where, args := filter.where()
+	query := `
+		SELECT m.id, m.state, ` + mailRecipients + `, COALESCE(msg.subject, ''),
+			COALESCE(msg.model, ''), COALESCE(m.failure_reason, ''), m.create_date
+		FROM mail_mail m
+		JOIN mail_message msg ON msg.id = m.mail_message_id
+		WHERE ` + where + `
+		ORDER BY m.id DESC
+	`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query mail: %w", err)
+	}
+	defer rows.Close()
+
+	var mails []QueuedMail
+	for rows.Next() {
+		var m QueuedMail
+		if err := rows.Scan(&m.ID, &m.State, &m.Recipients, &m.Subject, &m.Model, &m.FailureReason, &m.CreateDate); err != nil {
+			return nil, fmt.Errorf("failed to scan mail: %w", err)
+		}
+		mails = append(mails, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating mail: %w", err)
+	}
+
+	return mails, nil
+}
+
+// SetMailState moves the mail_mail records matching filter to state and
+// returns how many changed. Requeued mail loses its failure reason, as
+// when it is retried from Odoo.
+func SetMailState(ctx context.Context, db *sql.DB, filter MailFilter, state string) (int64, error) {
+	columns, err := tableColumns(ctx, db, "mail_mail")
+	if err != nil {
+		return 0, err
+	}
+	where, args := filter.where()
+	// state follows the filter arguments
+	args = append(args, state)
+	param := fmt.Sprintf("$%d", len(args))
+	set := "state = " + param + ", write_date = NOW(), write_uid = 1"
+	if state == "outgoing" {
+		set += ", failure_reason = NULL"
+		if columns["failure_type"] {
+			set += ", failure_type = NULL"
+		}
+	}
+	query := `
+		UPDATE mail_mail m SET ` + set + `
+		FROM mail_message msg
+		WHERE msg.id = m.mail_message_id AND m.state <> ` + param + ` AND ` + where
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update mail: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MaintenanceOptions selects the maintenance run on each table
+type MaintenanceOptions struct {
+	Vacuum  bool
+	Analyze bool
+	// Full rewrites the tables with VACUUM FULL, which gives the space
+	// back to the system but locks the table for the whole run
+	Full    bool
+	Reindex bool
+	// LockTimeout bounds the wait for each table lock, so maintenance
+	// gives up on a busy table instead of queueing the Odoo workers
+	// behind it
+	LockTimeout time.Duration
+}
+
+// MaintenanceResult is the outcome of the maintenance of one table
+type MaintenanceResult struct {
+	Table      string
+	SizeBefore int64
+	SizeAfter  int64
+	Duration   time.Duration
+	// Skipped is set when the table lock could not be acquired in time
+	Skipped bool
+	Err     error
+}
+
+// Maintainer runs maintenance statements on a dedicated connection, so
+// its session settings apply to all of them
+type Maintainer struct {
+	conn         *sql.Conn
+	opts         MaintenanceOptions
+	concurrently bool
+}
+
+// NewMaintainer reserves a connection of db and sets its lock timeout
+func NewMaintainer(ctx context.Context, db *sql.DB, opts MaintenanceOptions) (*Maintainer, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	m := &Maintainer{conn: conn, opts: opts}
+
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET lock_timeout = %d", opts.LockTimeout.Milliseconds())); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+	var version int
+	if err := conn.QueryRowContext(ctx, "SHOW server_version_num").Scan(&version); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+	// REINDEX CONCURRENTLY exists since PostgreSQL 12
+	m.concurrently = version >= 120000
+	return m, nil
+}
+
+// Close releases the connection
+func (m *Maintainer) Close() error {
+	return m.conn.Close()
+}
+
+// statements returns the SQL run on table
+func (m *Maintainer) statements(table string) []string {
+	ident := pq.QuoteIdentifier(table)
+	var stmts []string
+	var options []string
+	if m.opts.Full {
+		options = append(options, "FULL")
+	}
+	if m.opts.Analyze {
+		options = append(options, "ANALYZE")
+	}
+	switch {
+	case m.opts.Vacuum || m.opts.Full:
+		if len(options) > 0 {
+			stmts = append(stmts, fmt.Sprintf("VACUUM (%s) %s", strings.Join(options, ", "), ident))
+		} else {
+			stmts = append(stmts, "VACUUM "+ident)
+		}
+	case m.opts.Analyze:
+		stmts = append(stmts, "ANALYZE "+ident)
+	}
+	// VACUUM FULL already rebuilds the indexes
+	if m.opts.Reindex && !m.opts.Full {
+		if m.concurrently {
+			stmts = append(stmts, "REINDEX TABLE CONCURRENTLY "+ident)
+		} else {
+			stmts = append(stmts, "REINDEX TABLE "+ident)
+		}
+	}
+	return stmts
+}
+
+// Maintain runs the maintenance of one table and measures its size
+// before and after
+func (m *Maintainer) Maintain(ctx context.Context, table string) MaintenanceResult {
+	result := MaintenanceResult{Table: table}
+	sizeQuery := "SELECT pg_total_relation_size($1::regclass)"
+	if err := m.conn.QueryRowContext(ctx, sizeQuery, pq.QuoteIdentifier(table)).Scan(&result.SizeBefore); err != nil {
+		result.Err = fmt.Errorf("failed to get size of %s: %w", table, err)
+		return result
+	}
+
+	start := time.Now()
+	for _, stmt := range m.statements(table) {
+		if _, err := m.conn.ExecContext(ctx, stmt); err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == "55P03" {
+				result.Skipped = true
+				if strings.HasPrefix(stmt, "REINDEX TABLE CONCURRENTLY") {
+					result.Err = m.dropInvalidIndexes(ctx, table)
+				}
+			} else {
+				result.Err = fmt.Errorf("failed to run %s: %w", stmt, err)
+			}
+			break
+		}
+	}
+	result.Duration = time.Since(start)
+
+	if err := m.conn.QueryRowContext(ctx, sizeQuery, pq.QuoteIdentifier(table)).Scan(&result.SizeAfter); err != nil && result.Err == nil {
+		result.Err = fmt.Errorf("failed to get size of %s: %w", table, err)
+	}
+	return result
+}
+
+// dropInvalidIndexes drops the invalid indexes left on table by an
+// interrupted REINDEX CONCURRENTLY, which are still updated on every
+// write
+func (m *Maintainer) dropInvalidIndexes(ctx context.Context, table string) error {
+	rows, err := m.conn.QueryContext(ctx, `
+		SELECT c.relname FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indexrelid
+		WHERE i.indrelid = $1::regclass AND NOT i.indisvalid
+		AND c.relname LIKE '%\_ccnew%'
+	`, pq.QuoteIdentifier(table))
+	if err != nil {
+		return fmt.Errorf("failed to query invalid indexes of %s: %w", table, err)
+	}
+	var indexes []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan index name: %w", err)
+		}
+		indexes = append(indexes, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating indexes: %w", err)
+	}
+
+	for _, name := range indexes {
+		if _, err := m.conn.ExecContext(ctx, "DROP INDEX CONCURRENTLY IF EXISTS "+pq.QuoteIdentifier(name)); err != nil {
+			return fmt.Errorf("failed to drop invalid index %s left by REINDEX: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+// ModuleRecord is a module row of ir_module_module
+type ModuleRecord struct {
+	ID            int64
+	Name          string
+	State         string
+	LatestVersion string
+}
+
+// TransitionalStates are the module states left behind by an interrupted
+// install, upgrade or uninstall
+var TransitionalStates = []string{"to install", "to upgrade", "to remove"}
+
+// IsTransitionalState reports whether state is one of TransitionalStates
+func IsTransitionalState(state string) bool {
+	for _, s := range TransitionalStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// GetModules returns every module known to the database, sorted by name
+func GetModules(ctx context.Context, db *sql.DB) ([]ModuleRecord, error) {
+	query := `
+		SELECT id, name, state, COALESCE(latest_version, '')
+		FROM ir_module_module
+		ORDER BY name
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query modules: %w", err)
+	}
+	defer rows.Close()
+
+	var modules []ModuleRecord
+	for rows.Next() {
+		var m ModuleRecord
+		if err := rows.Scan(&m.ID, &m.Name, &m.State, &m.LatestVersion); err != nil {
+			return nil, fmt.Errorf("failed to scan module: %w", err)
+		}
+		modules = append(modules, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating modules: %w", err)
+	}
+
+	return modules, nil
+}
+
+// StableState returns the state a module in a transitional state had
+// before the operation was scheduled
+func StableState(state string) string {
+	if state == "to install" {
+		return "uninstalled"
+	}
+	if IsTransitionalState(state) {
+		return "installed"
+	}
+	return state
+}
+
+// StateReset is a module moved back to its stable state
+type StateReset struct {
+	Name string
+	From string
+	To   string
+}
+
+// orphanDependenciesWhere matches the ir_module_module_dependency rows
+// whose module or dependency is unknown to ir_module_module
+const orphanDependenciesWhere = `
+	module_id IS NULL
+	OR NOT EXISTS (SELECT 1 FROM ir_module_module m WHERE m.id = d.module_id)
+	OR NOT EXISTS (SELECT 1 FROM ir_module_module m WHERE m.name = d.name)
+`
+
+// CountOrphanDependencies counts the orphaned dependency rows
+func CountOrphanDependencies(ctx context.Context, db *sql.DB) (int64, error) {
+	var count int64
+	query := "SELECT count(*) FROM ir_module_module_dependency d WHERE " + orphanDependenciesWhere
+	if err := db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count orphan dependencies: %w", err)
+	}
+	return count, nil
+}
+
+// ResetModuleStates moves every module in a transitional state back to its
+// stable state and, with clearOrphans, deletes the orphaned dependency
+// rows, all in one transaction. It returns the modules reset and the
+// number of dependency rows deleted.
+func ResetModuleStates(ctx context.Context, db *sql.DB, clearOrphans bool) ([]StateReset, int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE ir_module_module m
+		SET state = CASE o.state WHEN 'to install' THEN 'uninstalled' ELSE 'installed' END
+		FROM (
+			SELECT id, state FROM ir_module_module
+			WHERE state = ANY($1)
+			FOR UPDATE
+		) o
+		WHERE m.id = o.id
+		RETURNING m.name, o.state, m.state
+	`
+	rows, err := tx.QueryContext(ctx, query, pq.Array(TransitionalStates))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to reset module states: %w", err)
+	}
+	var resets []StateReset
+	for rows.Next() {
+		var r StateReset
+		if err := rows.Scan(&r.Name, &r.From, &r.To); err != nil {
+			rows.Close()
+			return nil, 0, fmt.Errorf("failed to scan module state: %w", err)
+		}
+		resets = append(resets, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating module states: %w", err)
+	}
+
+	var cleared int64
+	if clearOrphans {
+		res, err := tx.ExecContext(ctx, "DELETE FROM ir_module_module_dependency d WHERE "+orphanDependenciesWhere)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to delete orphan dependencies: %w", err)
+		}
+		cleared, _ = res.RowsAffected()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit module states: %w", err)
+	}
+	sort.Slice(resets, func(i, j int) bool { return resets[i].Name < resets[j].Name })
+	return resets, cleared, nil
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ConfigParameter is an ir_config_parameter entry
+type ConfigParameter struct {
+	Key   string
+	Value string
+}
+
+// ListConfigParameters returns the parameters whose key starts with
+// prefix, sorted by key
+func ListConfigParameters(ctx context.Context, db *sql.DB, prefix string) ([]ConfigParameter, error) {
+	query := `
+		SELECT key, COALESCE(value, '') FROM ir_config_parameter
+		WHERE starts_with(key, $1)
+		ORDER BY key
+	`
+
+	rows, err := db.QueryContext(ctx, query, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query config parameters: %w", err)
+	}
+	defer rows.Close()
+
+	var params []ConfigParameter
+	for rows.Next() {
+		var p ConfigParameter
+		if err := rows.Scan(&p.Key, &p.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan config parameter: %w", err)
+		}
+		params = append(params, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating config parameters: %w", err)
+	}
+
+	return params, nil
+}
+
+// LookupConfigParameter gets an ir_config_parameter value and whether it
+// is set at all
+func LookupConfigParameter(ctx context.Context, db *sql.DB, key string) (string, bool, error) {
+	var value sql.NullString
+	query := "SELECT value FROM ir_config_parameter WHERE key = $1"
+	err := db.QueryRowContext(ctx, query, key).Scan(&value)
+
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get config parameter: %w", err)
+	}
+
+	return value.String, true, nil
+}
+
+// DeleteConfigParameter removes an ir_config_parameter and reports whether
+// it existed
+func DeleteConfigParameter(ctx context.Context, db *sql.DB, key string) (bool, error) {
+	res, err := db.ExecContext(ctx, "DELETE FROM ir_config_parameter WHERE key = $1", key)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete config parameter: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
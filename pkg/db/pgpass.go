@@ -0,0 +1,148 @@
+package db
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// PgpassEntry is a line of a PostgreSQL password file
+type PgpassEntry struct {
+	Host     string
+	Port     string
+	Database string
+	User     string
+	Password string
+}
+
+// PgpassFile returns the password file libpq would read
+func PgpassFile() string {
+	if path := os.Getenv("PGPASSFILE"); path != "" {
+		return path
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "postgresql", "pgpass.conf")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".pgpass")
+}
+
+// ReadPgpass parses a PostgreSQL password file
+func ReadPgpass(path string) ([]PgpassEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []PgpassEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := splitPgpassLine(line)
+		if len(fields) != 5 {
+			continue
+		}
+		entries = append(entries, PgpassEntry{
+			Host:     fields[0],
+			Port:     fields[1],
+			Database: fields[2],
+			User:     fields[3],
+			Password: fields[4],
+		})
+	}
+	return entries, scanner.Err()
+}
+
+// splitPgpassLine splits on unescaped colons and removes the escapes
+func splitPgpassLine(line string) []string {
+	var fields []string
+	var current strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line):
+			i++
+			current.WriteByte(line[i])
+		case c == ':':
+			fields = append(fields, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	return append(fields, current.String())
+}
+
+// DetectPGConfig builds the connection settings from the PG* environment
+// variables, completing the password from the password file
+func DetectPGConfig() *PGConfig {
+	cfg := DefaultPGConfig()
+	if host := os.Getenv("PGHOST"); host != "" {
+		cfg.Host = host
+	}
+	if port, err := strconv.Atoi(os.Getenv("PGPORT")); err == nil && port > 0 {
+		cfg.Port = port
+	}
+	userSet := false
+	if user := os.Getenv("PGUSER"); user != "" {
+		cfg.User = user
+		userSet = true
+	}
+	cfg.Password = os.Getenv("PGPASSWORD")
+	if cfg.Password != "" {
+		return cfg
+	}
+
+	if userSet {
+		cfg.Password = PgpassPassword(cfg)
+		return cfg
+	}
+
+	// Take the user and password of the same entry, the first one for
+	// the host and port
+	entries, err := ReadPgpass(PgpassFile())
+	if err != nil {
+		return cfg
+	}
+	port := strconv.Itoa(cfg.Port)
+	for _, e := range entries {
+		if !pgpassMatch(e.Host, cfg.Host) || !pgpassMatch(e.Port, port) {
+			continue
+		}
+		if e.User != "*" {
+			cfg.User = e.User
+		}
+		cfg.Password = e.Password
+		break
+	}
+	return cfg
+}
+
+// PgpassPassword returns the password of the first password file entry
+// matching the host, port and user of cfg, or "" when none does
+func PgpassPassword(cfg *PGConfig) string {
+	entries, err := ReadPgpass(PgpassFile())
+	if err != nil {
+		return ""
+	}
+	port := strconv.Itoa(cfg.Port)
+	for _, e := range entries {
+		if pgpassMatch(e.Host, cfg.Host) && pgpassMatch(e.Port, port) && pgpassMatch(e.User, cfg.User) {
+			return e.Password
+		}
+	}
+	return ""
+}
+
+func pgpassMatch(pattern, value string) bool {
+	return pattern == "*" || pattern == value
+}
@@ -194,15 +194,40 @@ func DropDatabase(ctx context.Context, dbname string, cfg *PGConfig) error {
 	return nil
 }
 
-// terminateConnections terminates all connections to a database
+// terminateConnections terminates all connections to a database, logging
+// each session terminated
 func terminateConnections(ctx context.Context, db *sql.DB, dbname string) error {
-	query := `
-		SELECT pg_terminate_backend(pid)
-		FROM pg_stat_activity
-		WHERE datname = $1 AND pid <> pg_backend_pid()
-	`
-	_, err := db.ExecContext(ctx, query, dbname)
-	return err
+	sessions, err := ListActivity(ctx, db, dbname)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return nil
+	}
+	pids := make([]int64, len(sessions))
+	for i, a := range sessions {
+		pids[i] = a.PID
+	}
+	terminated, err := TerminateBackends(ctx, db, pids)
+	if err != nil {
+		return err
+	}
+	done := make(map[int64]bool, len(terminated))
+	for _, pid := range terminated {
+		done[pid] = true
+	}
+	for _, a := range sessions {
+		if done[a.PID] {
+			log.WithFields(logrus.Fields{
+				"database":    dbname,
+				"pid":         a.PID,
+				"user":        a.User,
+				"application": a.Application,
+				"state":       a.State,
+			}).Warn("Terminated connection")
+		}
+	}
+	return nil
 }
 
 // CreateDatabaseFromTemplate creates a database from a template
@@ -339,7 +364,12 @@ func ResetConfigParameters(ctx context.Context, db *sql.DB) error {
 	return nil
 }
 
-// ListDatabases lists all databases
+// TemplatePrefix marks the cached template databases built by initdb
+// --cached. They are left out of the database lists, since a session on
+// a template makes CREATE DATABASE ... TEMPLATE fail.
+const TemplatePrefix = "ocli_tpl_"
+
+// ListDatabases lists all databases but the cached templates
 func ListDatabases(ctx context.Context, cfg *PGConfig) ([]string, error) {
 	db, err := Connect(ctx, "postgres", cfg)
 	if err != nil {
@@ -351,10 +381,11 @@ func ListDatabases(ctx context.Context, cfg *PGConfig) ([]string, error) {
 		SELECT datname FROM pg_database
 		WHERE datistemplate = false
 		AND datname != 'postgres'
+		AND NOT starts_with(datname, $1)
 		ORDER BY datname
 	`
 
-	rows, err := db.QueryContext(ctx, query)
+	rows, err := db.QueryContext(ctx, query, TemplatePrefix)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query databases: %w", err)
 	}
@@ -404,11 +435,11 @@ func GetDatabaseSize(ctx context.Context, dbname string, cfg *PGConfig) (string,
 		return "", err
 	}
 
-	return formatBytes(size), nil
+	return FormatBytes(size), nil
 }
 
-// formatBytes converts bytes to a human-readable format
-func formatBytes(bytes int64) string {
+// FormatBytes converts bytes to a human-readable format
+func FormatBytes(bytes int64) string {
 	const unit = 1024
 	if bytes < unit {
 		return fmt.Sprintf("%d B", bytes)
@@ -424,6 +455,68 @@ func formatBytes(bytes int64) string {
 	return fmt.Sprintf("%.2f %s", float64(bytes)/float64(div), units[exp])
 }
 
+// DatabaseInfo describes a database with its comment and size
+type DatabaseInfo struct {
+	Name    string
+	Comment string
+	Size    int64
+}
+
+// ListDatabasesWithPrefix lists databases whose name starts with prefix,
+// including their comment and size
+func ListDatabasesWithPrefix(ctx context.Context, prefix string, cfg *PGConfig) ([]DatabaseInfo, error) {
+	db, err := Connect(ctx, "postgres", cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer CloseDB(db)
+
+	query := `
+		SELECT datname,
+			COALESCE(shobj_description(oid, 'pg_database'), ''),
+			pg_database_size(datname)
+		FROM pg_database
+		WHERE datistemplate = false
+		AND starts_with(datname, $1)
+		ORDER BY datname
+	`
+
+	rows, err := db.QueryContext(ctx, query, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query databases: %w", err)
+	}
+	defer rows.Close()
+
+	var databases []DatabaseInfo
+	for rows.Next() {
+		var info DatabaseInfo
+		if err := rows.Scan(&info.Name, &info.Comment, &info.Size); err != nil {
+			return nil, fmt.Errorf("failed to scan database: %w", err)
+		}
+		databases = append(databases, info)
+	}
+
+	return databases, rows.Err()
+}
+
+// SetDatabaseComment sets the comment of a database
+func SetDatabaseComment(ctx context.Context, dbname, comment string, cfg *PGConfig) error {
+	db, err := Connect(ctx, "postgres", cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer CloseDB(db)
+
+	query := fmt.Sprintf("COMMENT ON DATABASE %s IS %s",
+		pq.QuoteIdentifier(dbname),
+		pq.QuoteLiteral(comment))
+
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to set database comment: %w", err)
+	}
+	return nil
+}
+
 // SetConfigParameter sets an ir_config_parameter value
 func SetConfigParameter(ctx context.Context, db *sql.DB, key, value string) error {
 	query := `
@@ -0,0 +1,364 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// QueryResult is the outcome of an ad-hoc statement: the rows it
+// returned, rendered as text, or the number of rows it changed
+type QueryResult struct {
+	Columns []string
+	Rows    [][]string
+	// Affected is -1 for statements that neither return nor change rows
+	Affected int64
+}
+
+// sqlToken is a word or punctuation of a script outside of comments,
+// quoted strings and dollar-quoted bodies, with its parenthesis depth
+type sqlToken struct {
+	text  string
+	depth int
+}
+
+// dollarTag matches the opening of a dollar-quoted string, e.g. $$ or $fn$
+var dollarTag = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// sqlStatement is one statement of a script: its text and its tokens
+type sqlStatement struct {
+	text   string
+	tokens []sqlToken
+}
+
+// sqlStatements splits a script into its statements
+func sqlStatements(script string) []sqlStatement {
+	var statements []sqlStatement
+	var current []sqlToken
+	start := 0
+	// appendToken adds a token starting at offset i of the script
+	appendToken := func(t sqlToken, i int) {
+		if len(current) == 0 {
+			start = i
+		}
+		current = append(current, t)
+	}
+	depth := 0
+	for i := 0; i < len(script); {
+		c := script[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case strings.HasPrefix(script[i:], "--"):
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(script)
+			}
+		case strings.HasPrefix(script[i:], "/*"):
+			// Block comments nest in PostgreSQL
+			nested := 0
+			for i < len(script) {
+				if strings.HasPrefix(script[i:], "/*") {
+					nested++
+					i += 2
+				} else if strings.HasPrefix(script[i:], "*/") {
+					nested--
+					i += 2
+					if nested == 0 {
+						break
+					}
+				} else {
+					i++
+				}
+			}
+		case c == '\'' || c == '"':
+			// E'...' strings allow backslash escapes
+			escapes := c == '\'' && i > 0 && (script[i-1] == 'E' || script[i-1] == 'e') &&
+				(i == 1 || !isWordChar(script[i-2]))
+			j := i + 1
+			for j < len(script) {
+				if escapes && script[j] == '\\' {
+					j += 2
+					continue
+				}
+				if script[j] == c {
+					if j+1 < len(script) && script[j+1] == c {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if c == '"' {
+				appendToken(sqlToken{script[i:min(j+1, len(script))], depth}, i)
+			}
+			i = j + 1
+		case c == '$' && dollarTag.MatchString(script[i:]):
+			tag := dollarTag.FindString(script[i:])
+			if end := strings.Index(script[i+len(tag):], tag); end >= 0 {
+				i += len(tag) + end + len(tag)
+			} else {
+				i = len(script)
+			}
+		case isWordChar(c):
+			j := i
+			for j < len(script) && isWordChar(script[j]) {
+				j++
+			}
+			appendToken(sqlToken{script[i:j], depth}, i)
+			i = j
+		case c == ';':
+			if len(current) > 0 {
+				statements = append(statements, sqlStatement{strings.TrimSpace(script[start:i]), current})
+			}
+			current = nil
+			depth = 0
+			i++
+		default:
+			if c == ')' {
+				depth--
+			}
+			appendToken(sqlToken{string(c), depth}, i)
+			if c == '(' {
+				depth++
+			}
+			i++
+		}
+	}
+	if len(current) > 0 {
+		statements = append(statements, sqlStatement{strings.TrimSpace(script[start:]), current})
+	}
+	return statements
+}
+
+// mainStatement returns the index of the keyword of a statement, after
+// its WITH clause if any, e.g. UPDATE in WITH t AS (...) UPDATE ...
+func mainStatement(tokens []sqlToken) int {
+	i := 0
+	for i < len(tokens) && tokens[i].text == "(" {
+		i++
+	}
+	if i == len(tokens) || !strings.EqualFold(tokens[i].text, "WITH") {
+		return i
+	}
+	depth := tokens[i].depth
+	for j := i + 1; j < len(tokens)-1; j++ {
+		if tokens[j].text != ")" || tokens[j].depth != depth {
+			continue
+		}
+		// A CTE body or column list ends: what follows is another CTE,
+		// its body or the statement itself
+		next := tokens[j+1].text
+		if next != "," && !strings.EqualFold(next, "AS") {
+			return j + 1
+		}
+	}
+	return len(tokens)
+}
+
+// changesRows tells whether a statement is a data change without a
+// RETURNING clause, whose affected row count is worth reporting
+func changesRows(query string) bool {
+	statements := sqlStatements(query)
+	if len(statements) == 0 {
+		return false
+	}
+	tokens := statements[0].tokens
+	i := mainStatement(tokens)
+	if i == len(tokens) {
+		return false
+	}
+	switch strings.ToUpper(tokens[i].text) {
+	case "INSERT", "UPDATE", "DELETE", "MERGE":
+		for _, t := range tokens[i+1:] {
+			if t.depth == tokens[i].depth && strings.EqualFold(t.text, "RETURNING") {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// transactionControl returns the first statement of a script that ends
+// or starts a transaction, which would defeat the rollback of a dry run
+func transactionControl(script string) string {
+	for _, stmt := range sqlStatements(script) {
+		tokens := stmt.tokens
+		words := make([]string, 0, 3)
+		for _, t := range tokens[:min(3, len(tokens))] {
+			words = append(words, strings.ToUpper(t.text))
+		}
+		switch words[0] {
+		case "BEGIN", "START", "COMMIT", "END", "ABORT":
+			return words[0]
+		case "ROLLBACK":
+			// ROLLBACK [WORK | TRANSACTION] TO SAVEPOINT stays in the transaction
+			if !slices.Contains(words, "TO") {
+				return words[0]
+			}
+		case "PREPARE":
+			if len(words) > 1 && words[1] == "TRANSACTION" {
+				return "PREPARE TRANSACTION"
+			}
+		}
+	}
+	return ""
+}
+
+// RunQuery runs one statement in a transaction, which is rolled back
+// instead of committed when dryRun is set
+func RunQuery(ctx context.Context, db *sql.DB, query string, dryRun bool) (*QueryResult, error) {
+	if stmt := transactionControl(query); stmt != "" {
+		return nil, fmt.Errorf("%s cannot be used, the query already runs in a transaction", stmt)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &QueryResult{Affected: -1}
+	if changesRows(query) {
+		res, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		result.Affected, _ = res.RowsAffected()
+	} else if err := scanQueryRows(ctx, tx, query, result); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return result, tx.Rollback()
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+func scanQueryRows(ctx context.Context, tx *sql.Tx, query string, result *QueryResult) error {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return fmt.Errorf("failed to get columns: %w", err)
+	}
+	for _, t := range types {
+		result.Columns = append(result.Columns, t.Name())
+	}
+	values := make([]interface{}, len(types))
+	ptrs := make([]interface{}, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		row := make([]string, len(values))
+		for i, v := range values {
+			row[i] = formatValue(v, types[i].DatabaseTypeName())
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return nil
+}
+
+// formatValue renders a column value of the given type as psql would
+func formatValue(v interface{}, typeName string) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if typeName == "BYTEA" {
+			return `\x` + hex.EncodeToString(v)
+		}
+		return string(v)
+	case time.Time:
+		switch typeName {
+		case "DATE":
+			return v.Format(time.DateOnly)
+		case "TIMESTAMPTZ":
+			return v.Format("2006-01-02 15:04:05.999999-07")
+		}
+		return v.Format("2006-01-02 15:04:05.999999")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// RunScript runs a script of several statements, in one transaction
+// unless useTx is false. With dryRun the transaction is rolled back.
+func RunScript(ctx context.Context, db *sql.DB, script string, useTx, dryRun bool) error {
+	if !useTx {
+		if dryRun {
+			return errors.New("a dry run needs a transaction to roll back")
+		}
+		return runStatements(ctx, db, script)
+	}
+
+	// A COMMIT in the script would end the transaction early, and the
+	// rollback of a dry run would then have nothing to undo
+	if stmt := transactionControl(script); stmt != "" {
+		return fmt.Errorf("%s cannot be used, the script already runs in a transaction; remove it or use --no-transaction", stmt)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if dryRun {
+		return tx.Rollback()
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// runStatements runs the statements of a script one by one in autocommit
+// mode, on one connection so that session settings carry over. Sent as a
+// single query, they would run in one implicit transaction, where e.g.
+// CREATE INDEX CONCURRENTLY is refused.
+func runStatements(ctx context.Context, db *sql.DB, script string) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	statements := sqlStatements(script)
+	for i, stmt := range statements {
+		if _, err := conn.ExecContext(ctx, stmt.text); err != nil {
+			return fmt.Errorf("statement %d of %d failed: %w", i+1, len(statements), err)
+		}
+	}
+	return nil
+}
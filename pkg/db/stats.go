@@ -0,0 +1,184 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TableStat describes the size and the tuples of a table
+type TableStat struct {
+	Table string
+	// Model is the Odoo model stored in the table, empty for relation
+	// tables and tables not managed by the ORM
+	Model string
+	// EstimatedRows comes from the planner statistics, -1 if never analyzed
+	EstimatedRows int64
+	LiveTuples    int64
+	DeadTuples    int64
+	HeapSize      int64
+	ToastSize     int64
+	IndexSize     int64
+	TotalSize     int64
+	LastVacuum    *time.Time
+	LastAnalyze   *time.Time
+}
+
+// BloatRatio is the share of dead tuples in the table, which VACUUM
+// makes reusable and VACUUM FULL gives back to the system
+func (t TableStat) BloatRatio() float64 {
+	if t.LiveTuples+t.DeadTuples == 0 {
+		return 0
+	}
+	return float64(t.DeadTuples) / float64(t.LiveTuples+t.DeadTuples)
+}
+
+// IndexStat describes the size and the usage of an index
+type IndexStat struct {
+	Name  string
+	Table string
+	Size  int64
+	Scans int64
+}
+
+// TableStats returns the tables of the current schema, largest first.
+// Sizes are in bytes; the last vacuum and analyze are manual or automatic,
+// whichever is newer.
+func TableStats(ctx context.Context, db *sql.DB) ([]TableStat, error) {
+	query := `
+		SELECT c.relname, c.reltuples::bigint,
+			COALESCE(s.n_live_tup, 0), COALESCE(s.n_dead_tup, 0),
+			pg_relation_size(c.oid),
+			CASE WHEN c.reltoastrelid <> 0 THEN pg_total_relation_size(c.reltoastrelid) ELSE 0 END,
+			pg_indexes_size(c.oid),
+			pg_total_relation_size(c.oid),
+			GREATEST(s.last_vacuum, s.last_autovacuum),
+			GREATEST(s.last_analyze, s.last_autoanalyze)
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_stat_user_tables s ON s.relid = c.oid
+		WHERE c.relkind IN ('r', 'p', 'm') AND n.nspname = current_schema()
+		ORDER BY pg_total_relation_size(c.oid) DESC, c.relname
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []TableStat
+	for rows.Next() {
+		var t TableStat
+		var vacuum, analyze sql.NullTime
+		if err := rows.Scan(&t.Table, &t.EstimatedRows, &t.LiveTuples, &t.DeadTuples, &t.HeapSize,
+			&t.ToastSize, &t.IndexSize, &t.TotalSize, &vacuum, &analyze); err != nil {
+			return nil, fmt.Errorf("failed to scan table stats: %w", err)
+		}
+		if vacuum.Valid {
+			t.LastVacuum = &vacuum.Time
+		}
+		if analyze.Valid {
+			t.LastAnalyze = &analyze.Time
+		}
+		stats = append(stats, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating table stats: %w", err)
+	}
+
+	models, err := modelTables(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		stats[i].Model = models[stats[i].Table]
+	}
+	return stats, nil
+}
+
+// customTables are the tables of the core models whose _table is not the
+// default one. The _table of other modules is not stored in the database,
+// so their custom tables are not mapped back to a model.
+var customTables = map[string]string{
+	"ir.actions.actions":         "ir_actions",
+	"ir.actions.act_window":      "ir_act_window",
+	"ir.actions.act_window.view": "ir_act_window_view",
+	"ir.actions.act_url":         "ir_act_url",
+	"ir.actions.server":          "ir_act_server",
+	"ir.actions.client":          "ir_act_client",
+	"ir.actions.report":          "ir_act_report_xml",
+}
+
+// modelTables maps table names to the Odoo models of ir_model, whose
+// default table is the model name with dots replaced by underscores
+func modelTables(ctx context.Context, db *sql.DB) (map[string]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT model FROM ir_model")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query models: %w", err)
+	}
+	defer rows.Close()
+
+	models := make(map[string]string)
+	for rows.Next() {
+		var model string
+		if err := rows.Scan(&model); err != nil {
+			return nil, fmt.Errorf("failed to scan model: %w", err)
+		}
+		table, ok := customTables[model]
+		if !ok {
+			table = strings.ReplaceAll(model, ".", "_")
+		}
+		models[table] = model
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating models: %w", err)
+	}
+
+	return models, nil
+}
+
+// IndexStats returns the indexes of the current schema, largest first,
+// with how many times they were used since the statistics were reset
+func IndexStats(ctx context.Context, db *sql.DB) ([]IndexStat, error) {
+	query := `
+		SELECT indexrelname, relname, pg_relation_size(indexrelid), idx_scan
+		FROM pg_stat_user_indexes
+		WHERE schemaname = current_schema()
+		ORDER BY pg_relation_size(indexrelid) DESC, indexrelname
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query index stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []IndexStat
+	for rows.Next() {
+		var s IndexStat
+		if err := rows.Scan(&s.Name, &s.Table, &s.Size, &s.Scans); err != nil {
+			return nil, fmt.Errorf("failed to scan index stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating index stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// CurrentDatabaseSize returns the size in bytes of the connected database
+func CurrentDatabaseSize(ctx context.Context, db *sql.DB) (int64, error) {
+	var size int64
+	if err := db.QueryRowContext(ctx, "SELECT pg_database_size(current_database())").Scan(&size); err != nil {
+		return 0, fmt.Errorf("failed to get database size: %w", err)
+	}
+	return size, nil
+}
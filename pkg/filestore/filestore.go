@@ -0,0 +1,237 @@
+package filestore
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mjavint/ocli/pkg/db"
+)
+
+// ChecklistDir is the directory where Odoo records the files that may
+// have become garbage; it is not part of the stored content
+const ChecklistDir = "checklist"
+
+// File is a file of the filestore
+type File struct {
+	// Fname is the path relative to the filestore, as in store_fname
+	Fname   string
+	Size    int64
+	ModTime time.Time
+}
+
+// Mismatch is a file whose content does not match its attachment checksum
+type Mismatch struct {
+	Attachment db.StoredAttachment
+	Actual     string
+}
+
+// Report is the result of cross-checking the filestore with ir_attachment
+type Report struct {
+	Files      int
+	Missing    []db.StoredAttachment
+	Mismatched []Mismatch
+	Orphans    []File
+}
+
+// OrphanSize is the total size of the orphaned files
+func (r *Report) OrphanSize() int64 {
+	var size int64
+	for _, f := range r.Orphans {
+		size += f.Size
+	}
+	return size
+}
+
+// OK tells whether nothing is missing nor corrupted; orphans are harmless
+func (r *Report) OK() bool {
+	return len(r.Missing) == 0 && len(r.Mismatched) == 0
+}
+
+// Scan lists the stored files of a filestore, skipping the checklist
+func Scan(dir string) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if d.IsDir() {
+			if rel == ChecklistDir {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, File{Fname: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Fname < files[j].Fname })
+	return files, nil
+}
+
+// Checksum returns the SHA-1 of a file, as stored in ir_attachment.checksum
+func Checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Check cross-references the files of dir with the attachments. With
+// verify, the content of every referenced file is hashed and compared
+// with the checksum of its attachments.
+func Check(dir string, files []File, attachments []db.StoredAttachment, verify bool) (*Report, error) {
+	report := &Report{Files: len(files)}
+	onDisk := make(map[string]bool, len(files))
+	for _, f := range files {
+		onDisk[f.Fname] = true
+	}
+
+	referenced := make(map[string]bool, len(attachments))
+	checksums := make(map[string]string)
+	for _, a := range attachments {
+		referenced[a.StoreFname] = true
+		if !onDisk[a.StoreFname] {
+			report.Missing = append(report.Missing, a)
+			continue
+		}
+		if !verify || a.Checksum == "" {
+			continue
+		}
+		// Identical contents share one file, hash it once
+		actual, ok := checksums[a.StoreFname]
+		if !ok {
+			sum, err := Checksum(filepath.Join(dir, filepath.FromSlash(a.StoreFname)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to hash %s: %w", a.StoreFname, err)
+			}
+			actual = sum
+			checksums[a.StoreFname] = sum
+		}
+		if actual != a.Checksum {
+			report.Mismatched = append(report.Mismatched, Mismatch{Attachment: a, Actual: actual})
+		}
+	}
+
+	for _, f := range files {
+		if !referenced[f.Fname] {
+			report.Orphans = append(report.Orphans, f)
+		}
+	}
+	return report, nil
+}
+
+// Remove deletes an orphaned file and its checklist entry
+func Remove(dir, fname string) error {
+	if err := os.Remove(Path(dir, fname)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	removeChecklistEntry(dir, fname)
+	return nil
+}
+
+// Quarantine moves an orphaned file to the same relative path below
+// quarantine, so it can be restored by moving it back
+func Quarantine(dir, quarantine, fname string) error {
+	dest := filepath.Join(quarantine, filepath.FromSlash(fname))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(dir, filepath.FromSlash(fname)), dest); err != nil {
+		return err
+	}
+	removeChecklistEntry(dir, fname)
+	return nil
+}
+
+// removeChecklistEntry drops fname from the checklist, as Odoo does once
+// it has decided about a file. Directories are kept, Odoo may be writing
+// a new file in them.
+func removeChecklistEntry(dir, fname string) {
+	os.Remove(filepath.Join(dir, ChecklistDir, filepath.FromSlash(fname)))
+}
+
+// InChecklist tells whether Odoo marked fname as a garbage candidate
+func InChecklist(dir, fname string) bool {
+	_, err := os.Stat(filepath.Join(dir, ChecklistDir, filepath.FromSlash(fname)))
+	return err == nil
+}
+
+// Path returns the path of a store_fname in the filestore dir
+func Path(dir, fname string) string {
+	return filepath.Join(dir, filepath.FromSlash(fname))
+}
+
+// Write stores content as Odoo does, under the first two characters of
+// its SHA-1, and returns its store_fname and checksum. Identical content
+// already stored is reused.
+func Write(dir string, content []byte) (fname, checksum string, err error) {
+	sum := sha1.Sum(content)
+	checksum = hex.EncodeToString(sum[:])
+	fname = checksum[:2] + "/" + checksum
+	path := Path(dir, fname)
+	if info, err := os.Stat(path); err == nil && info.Size() == int64(len(content)) {
+		return fname, checksum, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", "", err
+	}
+	// Write aside and rename, so a file is either complete or absent
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", "", err
+	}
+	return fname, checksum, nil
+}
+
+// MarkForGC adds fname to the checklist, so the garbage collection of
+// Odoo (and ocli filestore gc --checklist-only) considers it
+func MarkForGC(dir, fname string) error {
+	entry := filepath.Join(dir, ChecklistDir, filepath.FromSlash(fname))
+	if err := os.MkdirAll(filepath.Dir(entry), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(entry, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,32 @@
+//go:build !windows
+
+package instance
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// processAlive checks the pid with signal 0
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return proc.Signal(syscall.Signal(0)) == nil
+}
+
+// Detach makes cmd survive the terminal that started it
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// Terminate asks the process to stop gracefully
+func Terminate(pid int) error {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return proc.Signal(syscall.SIGTERM)
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package instance
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+const processQueryLimitedInformation = 0x1000
+
+// processAlive opens the process to check that the pid still exists
+func processAlive(pid int) bool {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	const stillActive = 259
+	return code == stillActive
+}
+
+// Detach starts cmd in its own process group
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// Terminate stops the process; Windows has no SIGTERM
+func Terminate(pid int) error {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return proc.Kill()
+}
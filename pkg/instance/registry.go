@@ -0,0 +1,192 @@
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultName is the instance used when no name is given
+const DefaultName = "default"
+
+// Instance is the runtime state of an Odoo server started by ocli
+type Instance struct {
+	Name       string    `json:"name"`
+	OdooBin    string    `json:"odoo_bin"`
+	ConfigFile string    `json:"config_file"`
+	Database   string    `json:"database,omitempty"`
+	DataDir    string    `json:"data_dir,omitempty"`
+	LogFile    string    `json:"log_file,omitempty"`
+	HTTPPort   int       `json:"http_port"`
+	GeventPort int       `json:"gevent_port"`
+	PID        int       `json:"pid"`
+	Version    string    `json:"version,omitempty"`
+	Detached   bool      `json:"detached"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// Running reports whether the instance process is still alive
+func (i *Instance) Running() bool {
+	return i.PID > 0 && processAlive(i.PID)
+}
+
+// Registry stores instance state files under a directory
+type Registry struct {
+	dir string
+}
+
+// NewRegistry returns a registry rooted at dir
+func NewRegistry(dir string) *Registry {
+	return &Registry{dir: dir}
+}
+
+// Dir returns the registry directory
+func (r *Registry) Dir() string {
+	return r.dir
+}
+
+// ValidName reports whether name can be used as an instance name
+func ValidName(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') &&
+			!(c >= '0' && c <= '9') && c != '_' && c != '-' && c != '.' {
+			return false
+		}
+	}
+	return !strings.HasPrefix(name, ".")
+}
+
+func (r *Registry) stateFile(name string) string {
+	return filepath.Join(r.dir, name+".json")
+}
+
+// PIDFile returns the pidfile path handed to odoo-bin for an instance
+func (r *Registry) PIDFile(name string) string {
+	return filepath.Join(r.dir, name+".pid")
+}
+
+// LogFile returns the default log file of a detached instance
+func (r *Registry) LogFile(name string) string {
+	return filepath.Join(r.dir, name+".log")
+}
+
+// Save writes the instance state
+func (r *Registry) Save(inst *Instance) error {
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", r.dir, err)
+	}
+	content, err := json.MarshalIndent(inst, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal instance %s: %w", inst.Name, err)
+	}
+	return os.WriteFile(r.stateFile(inst.Name), content, 0644)
+}
+
+// Load reads the state of a named instance
+func (r *Registry) Load(name string) (*Instance, error) {
+	content, err := os.ReadFile(r.stateFile(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("instance %s not found", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instance %s: %w", name, err)
+	}
+	inst := &Instance{}
+	if err := json.Unmarshal(content, inst); err != nil {
+		return nil, fmt.Errorf("failed to parse instance %s: %w", name, err)
+	}
+	return inst, nil
+}
+
+// Remove deletes the state and pidfile of an instance
+func (r *Registry) Remove(name string) error {
+	for _, path := range []string{r.stateFile(name), r.PIDFile(name)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns every registered instance sorted by name
+func (r *Registry) List() ([]*Instance, error) {
+	matches, err := filepath.Glob(filepath.Join(r.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	instances := make([]*Instance, 0, len(matches))
+	for _, path := range matches {
+		inst, err := r.Load(strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, inst)
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Name < instances[j].Name
+	})
+	return instances, nil
+}
+
+// Running returns the live instances, removing stale state files
+func (r *Registry) Running() ([]*Instance, error) {
+	all, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	running := make([]*Instance, 0, len(all))
+	for _, inst := range all {
+		if inst.Running() {
+			running = append(running, inst)
+			continue
+		}
+		if err := r.Remove(inst.Name); err != nil {
+			return nil, fmt.Errorf("failed to clean stale instance %s: %w", inst.Name, err)
+		}
+	}
+	return running, nil
+}
+
+// UsedPorts returns the ports held by running instances, except skip
+func (r *Registry) UsedPorts(skip string) (map[int]bool, error) {
+	running, err := r.Running()
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[int]bool)
+	for _, inst := range running {
+		if inst.Name == skip {
+			continue
+		}
+		used[inst.HTTPPort] = true
+		used[inst.GeventPort] = true
+	}
+	return used, nil
+}
+
+// FreePort returns the first port in [from, to] that is neither reserved
+// in used nor bound by another process. The port is added to used.
+func FreePort(from, to int, used map[int]bool) (int, error) {
+	for port := from; port <= to; port++ {
+		if used[port] {
+			continue
+		}
+		ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			continue
+		}
+		ln.Close()
+		used[port] = true
+		return port, nil
+	}
+	return 0, fmt.Errorf("no free port in range %d-%d", from, to)
+}
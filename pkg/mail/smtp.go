@@ -0,0 +1,207 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAddr is where the catcher listens by default
+const DefaultAddr = "127.0.0.1:1025"
+
+// maxMessageSize bounds the DATA of a single message
+const maxMessageSize = 64 << 20
+
+// Envelope headers prepended to every stored message, so recipients that
+// do not appear in the headers (Bcc) are kept
+const (
+	HeaderMailFrom = "X-Ocli-Mail-From"
+	HeaderRcptTo   = "X-Ocli-Rcpt-To"
+)
+
+// Server is a minimal SMTP server that stores every message it receives
+// as an .eml file in Dir. It accepts any sender, recipient and
+// credentials, and does not support TLS.
+type Server struct {
+	Addr string
+	Dir  string
+	// OnMessage is called after each message is stored
+	OnMessage func(path string)
+
+	mu  sync.Mutex
+	seq int
+}
+
+// ListenAndServe accepts connections until ctx is done
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", s.Dir, err)
+	}
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.Addr, err)
+	}
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		go s.serve(conn)
+	}
+}
+
+// session is the state of one SMTP conversation
+type session struct {
+	from string
+	rcpt []string
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(code int, msg string) {
+		conn.SetWriteDeadline(time.Now().Add(time.Minute))
+		tp.PrintfLine("%d %s", code, msg)
+	}
+
+	reply(220, "ocli mail catcher ready")
+	var sess session
+	for {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Minute))
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			reply(250, "ocli")
+		case "EHLO":
+			conn.SetWriteDeadline(time.Now().Add(time.Minute))
+			tp.PrintfLine("250-ocli")
+			tp.PrintfLine("250-8BITMIME")
+			tp.PrintfLine("250-SMTPUTF8")
+			tp.PrintfLine("250-AUTH PLAIN LOGIN")
+			tp.PrintfLine("250 SIZE %d", maxMessageSize)
+		case "MAIL":
+			sess = session{from: addressArg(arg)}
+			reply(250, "OK")
+		case "RCPT":
+			sess.rcpt = append(sess.rcpt, addressArg(arg))
+			reply(250, "OK")
+		case "DATA":
+			if len(sess.rcpt) == 0 {
+				reply(503, "RCPT first")
+				continue
+			}
+			reply(354, "End data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(io.LimitReader(tp.DotReader(), maxMessageSize+1))
+			if err != nil {
+				return
+			}
+			if len(data) > maxMessageSize {
+				reply(552, "Message too big")
+				continue
+			}
+			path, err := s.store(sess, data)
+			if err != nil {
+				reply(451, "Failed to store message")
+				continue
+			}
+			if s.OnMessage != nil {
+				s.OnMessage(path)
+			}
+			reply(250, "OK queued as "+strings.TrimSuffix(filepath.Base(path), Ext))
+			sess = session{}
+		case "RSET":
+			sess = session{}
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "Bye")
+			return
+		case "AUTH":
+			// Accept any credentials, so servers configured with a login work
+			mech, initial, _ := strings.Cut(arg, " ")
+			prompts := 0
+			switch strings.ToUpper(mech) {
+			case "PLAIN":
+				if initial == "" {
+					prompts = 1
+				}
+			case "LOGIN":
+				prompts = 2
+				if initial != "" {
+					prompts = 1
+				}
+			default:
+				reply(504, "Unrecognized authentication type")
+				continue
+			}
+			for i := 0; i < prompts; i++ {
+				reply(334, "")
+				if _, err := tp.ReadLine(); err != nil {
+					return
+				}
+			}
+			reply(235, "Authentication successful")
+		default:
+			reply(502, "Command not implemented")
+		}
+	}
+}
+
+// store writes the message with its envelope headers and returns its path
+func (s *Server) store(sess session, data []byte) (string, error) {
+	s.mu.Lock()
+	s.seq++
+	name := fmt.Sprintf("%s-%04d%s", time.Now().Format("20060102-150405"), s.seq%10000, Ext)
+	s.mu.Unlock()
+
+	path := filepath.Join(s.Dir, name)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "%s: %s\n", HeaderMailFrom, sess.from)
+	fmt.Fprintf(w, "%s: %s\n", HeaderRcptTo, strings.Join(sess.rcpt, ", "))
+	w.Write(data)
+	err = errors.Join(w.Flush(), f.Close())
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// addressArg extracts the address of "FROM:<a@b> SIZE=1" or "TO:<a@b>"
+func addressArg(arg string) string {
+	_, addr, ok := strings.Cut(arg, ":")
+	if !ok {
+		return ""
+	}
+	addr = strings.TrimSpace(addr)
+	if i := strings.Index(addr, ">"); i >= 0 {
+		addr = addr[:i]
+	}
+	return strings.TrimPrefix(addr, "<")
+}
@@ -0,0 +1,212 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Ext is the extension of the stored messages
+const Ext = ".eml"
+
+// Message is the summary of a stored message
+type Message struct {
+	ID      string
+	Path    string
+	Date    time.Time
+	From    string
+	To      string
+	Subject string
+	Size    int64
+}
+
+var wordDecoder = &mime.WordDecoder{
+	CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+		// Only UTF-8 and ASCII are decoded, other charsets are shown as is
+		return input, nil
+	},
+}
+
+// decodeHeader decodes RFC 2047 encoded words, e.g. =?utf-8?q?...?=
+func decodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// List returns the messages stored in dir, oldest first
+func List(dir string) ([]Message, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var messages []Message
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != Ext {
+			continue
+		}
+		msg, err := readSummary(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
+
+// Find returns the message with the given id, which may be given with or
+// without the extension
+func Find(dir, id string) (Message, error) {
+	path := filepath.Join(dir, strings.TrimSuffix(filepath.Base(id), Ext)+Ext)
+	if _, err := os.Stat(path); err != nil {
+		return Message{}, fmt.Errorf("message %s not found in %s", id, dir)
+	}
+	return readSummary(path)
+}
+
+func readSummary(path string) (Message, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	msg := Message{
+		ID:   strings.TrimSuffix(filepath.Base(path), Ext),
+		Path: path,
+		Date: info.ModTime(),
+		Size: info.Size(),
+	}
+	m, err := mail.ReadMessage(f)
+	if err != nil {
+		// Keep unparsable messages visible, they can still be shown raw
+		return msg, nil
+	}
+	if date, err := m.Header.Date(); err == nil {
+		msg.Date = date
+	}
+	msg.From = decodeHeader(m.Header.Get("From"))
+	if msg.From == "" {
+		msg.From = m.Header.Get(HeaderMailFrom)
+	}
+	msg.To = decodeHeader(m.Header.Get("To"))
+	if msg.To == "" {
+		msg.To = m.Header.Get(HeaderRcptTo)
+	}
+	msg.Subject = decodeHeader(m.Header.Get("Subject"))
+	return msg, nil
+}
+
+// Body returns the headers of interest and the readable body of a
+// message: the text/plain part when there is one, the HTML part otherwise
+func Body(path string) (mail.Header, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+	m, err := mail.ReadMessage(f)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	plain, html, err := textParts(m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"), m.Body)
+	if err != nil {
+		return m.Header, "", fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	if plain != "" {
+		return m.Header, plain, nil
+	}
+	return m.Header, html, nil
+}
+
+// textParts walks a MIME entity and returns its first text/plain and
+// text/html bodies, decoded
+func textParts(contentType, encoding string, body io.Reader) (plain, html string, err error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return plain, html, err
+			}
+			if strings.HasPrefix(part.Header.Get("Content-Disposition"), "attachment") {
+				continue
+			}
+			p, h, err := textParts(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return plain, html, err
+			}
+			if plain == "" {
+				plain = p
+			}
+			if html == "" {
+				html = h
+			}
+		}
+		return plain, html, nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", "", nil
+	}
+	content, err := io.ReadAll(decodeTransfer(encoding, body))
+	if err != nil {
+		return "", "", err
+	}
+	if mediaType == "text/html" {
+		return "", string(content), nil
+	}
+	return string(content), "", nil
+}
+
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	case "base64":
+		// Line breaks are not part of the alphabet
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return bytes.NewReader(nil)
+		}
+		raw = bytes.Map(func(r rune) rune {
+			if r == '\r' || r == '\n' || r == ' ' {
+				return -1
+			}
+			return r
+		}, raw)
+		return base64.NewDecoder(base64.StdEncoding, bytes.NewReader(raw))
+	default:
+		return r
+	}
+}
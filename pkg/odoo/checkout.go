@@ -0,0 +1,52 @@
+package odoo
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// IsCheckout reports whether dir is an Odoo source tree with odoo-bin
+func IsCheckout(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, "odoo-bin")); err != nil {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(dir, "odoo", "release.py"))
+	return err == nil
+}
+
+// FindCheckouts looks for Odoo source trees in roots and in their
+// subdirectories up to depth levels below, and returns their odoo-bin
+func FindCheckouts(roots []string, depth int) []string {
+	var found []string
+	seen := make(map[string]bool)
+	var walk func(dir string, level int)
+	walk = func(dir string, level int) {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		if seen[dir] {
+			return
+		}
+		seen[dir] = true
+		if IsCheckout(dir) {
+			found = append(found, filepath.Join(dir, "odoo-bin"))
+			return
+		}
+		if level >= depth {
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			if e.IsDir() && e.Name()[0] != '.' && e.Name() != "node_modules" {
+				walk(filepath.Join(dir, e.Name()), level+1)
+			}
+		}
+	}
+	for _, root := range roots {
+		walk(root, 0)
+	}
+	return found
+}
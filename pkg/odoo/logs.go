@@ -0,0 +1,244 @@
+package odoo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogTimeLayout is the timestamp layout used by the Odoo log formatter.
+// Odoo forces TZ=UTC, so timestamps are parsed as UTC.
+const LogTimeLayout = "2006-01-02 15:04:05,000"
+
+var logHeaderRe = regexp.MustCompile(
+	`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2},\d{3}) (\d+) ([A-Z]+) (\S+) ([^\s:]+): ?(.*)$`,
+)
+
+// logLevels maps Odoo (Python logging) level names to their severity
+var logLevels = map[string]int{
+	"DEBUG":    10,
+	"INFO":     20,
+	"WARNING":  30,
+	"ERROR":    40,
+	"CRITICAL": 50,
+}
+
+// LogRecord is a single Odoo log record, including any continuation
+// lines (tracebacks, multi-line messages) that follow its header.
+type LogRecord struct {
+	Time     time.Time
+	PID      int
+	Level    string
+	DB       string
+	Logger   string
+	Message  string
+	Extra    []string
+	RawLines []string
+}
+
+// Raw returns the record exactly as it appeared in the log
+func (r *LogRecord) Raw() string {
+	return strings.Join(r.RawLines, "\n")
+}
+
+// Traceback returns the Python traceback attached to the record, if any
+func (r *LogRecord) Traceback() string {
+	for i, line := range r.Extra {
+		if strings.HasPrefix(line, "Traceback (most recent call last):") {
+			return strings.Join(r.Extra[i:], "\n")
+		}
+	}
+	return ""
+}
+
+// LevelValue returns the numeric severity of a level name, 0 if unknown
+func LevelValue(level string) int {
+	return logLevels[strings.ToUpper(level)]
+}
+
+// ValidLogLevel reports whether level is a known Odoo log level
+func ValidLogLevel(level string) bool {
+	_, ok := logLevels[strings.ToUpper(level)]
+	return ok
+}
+
+// LogParser assembles log lines into records. Feed it lines in order;
+// a record is complete once the next header line arrives or Flush is called.
+type LogParser struct {
+	pending *LogRecord
+}
+
+// Feed consumes one line and returns the previous record if the line
+// starts a new one.
+func (p *LogParser) Feed(line string) *LogRecord {
+	line = strings.TrimRight(line, "\r\n")
+	m := logHeaderRe.FindStringSubmatch(line)
+	if m == nil {
+		if p.pending == nil {
+			// Lines before the first header (e.g. a truncated traceback)
+			p.pending = &LogRecord{}
+		}
+		p.pending.Extra = append(p.pending.Extra, line)
+		p.pending.RawLines = append(p.pending.RawLines, line)
+		return nil
+	}
+
+	done := p.pending
+	ts, _ := time.ParseInLocation(LogTimeLayout, m[1], time.UTC)
+	var pid int
+	fmt.Sscanf(m[2], "%d", &pid)
+	p.pending = &LogRecord{
+		Time:     ts,
+		PID:      pid,
+		Level:    m[3],
+		DB:       m[4],
+		Logger:   m[5],
+		Message:  m[6],
+		RawLines: []string{line},
+	}
+	return done
+}
+
+// Flush returns the record being assembled, if any
+func (p *LogParser) Flush() *LogRecord {
+	done := p.pending
+	p.pending = nil
+	return done
+}
+
+// ParseLog reads every record from r and calls fn for each one
+func ParseLog(r io.Reader, fn func(*LogRecord) error) error {
+	parser := &LogParser{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if rec := parser.Feed(scanner.Text()); rec != nil {
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read log: %w", err)
+	}
+	if rec := parser.Flush(); rec != nil {
+		return fn(rec)
+	}
+	return nil
+}
+
+// LogFilter selects records by minimum level and start time
+type LogFilter struct {
+	MinLevel string
+	Since    time.Time
+}
+
+// Match reports whether the record passes the filter
+func (f LogFilter) Match(r *LogRecord) bool {
+	if f.MinLevel != "" && LevelValue(r.Level) < LevelValue(f.MinLevel) {
+		return false
+	}
+	if !f.Since.IsZero() && (r.Time.IsZero() || r.Time.Before(f.Since)) {
+		return false
+	}
+	return true
+}
+
+// FirstTraceback returns the first traceback found in the records, with
+// the header line of the record that carried it.
+func FirstTraceback(records []*LogRecord) string {
+	for _, r := range records {
+		tb := r.Traceback()
+		if tb == "" {
+			continue
+		}
+		if r.Level == "" {
+			// Headerless record: the traceback is all there is
+			return tb
+		}
+		return r.RawLines[0] + "\n" + tb
+	}
+	return ""
+}
+
+var (
+	normalizeQuotedRe = regexp.MustCompile(`'[^']*'|"[^"]*"`)
+	normalizeHexRe    = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	normalizeNumRe    = regexp.MustCompile(`\d+(\.\d+)?`)
+)
+
+// NormalizeMessage strips the variable parts of a log message (ids,
+// quoted values, numbers) so that similar messages group together.
+func NormalizeMessage(msg string) string {
+	msg = normalizeQuotedRe.ReplaceAllString(msg, "'…'")
+	msg = normalizeHexRe.ReplaceAllString(msg, "<id>")
+	msg = normalizeNumRe.ReplaceAllString(msg, "N")
+	return strings.TrimSpace(msg)
+}
+
+// LogGroup aggregates records sharing logger, level and normalized message
+type LogGroup struct {
+	Level     string
+	Logger    string
+	Message   string
+	Count     int
+	First     time.Time
+	Last      time.Time
+	Sample    *LogRecord
+	Traceback string
+}
+
+// LogSummary groups ERROR/WARNING records for `ocli logs summary`
+type LogSummary struct {
+	groups map[string]*LogGroup
+}
+
+// NewLogSummary creates an empty summary
+func NewLogSummary() *LogSummary {
+	return &LogSummary{groups: make(map[string]*LogGroup)}
+}
+
+// Add records r in its group
+func (s *LogSummary) Add(r *LogRecord) {
+	msg := NormalizeMessage(r.Message)
+	key := r.Level + "\x00" + r.Logger + "\x00" + msg
+	g, ok := s.groups[key]
+	if !ok {
+		g = &LogGroup{
+			Level:   r.Level,
+			Logger:  r.Logger,
+			Message: msg,
+			First:   r.Time,
+			Sample:  r,
+		}
+		s.groups[key] = g
+	}
+	g.Count++
+	g.Last = r.Time
+	if g.Traceback == "" {
+		g.Traceback = r.Traceback()
+	}
+}
+
+// Groups returns the groups sorted by severity, then by count
+func (s *LogSummary) Groups() []*LogGroup {
+	groups := make([]*LogGroup, 0, len(s.groups))
+	for _, g := range s.groups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		li, lj := LevelValue(groups[i].Level), LevelValue(groups[j].Level)
+		if li != lj {
+			return li > lj
+		}
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].First.Before(groups[j].First)
+	})
+	return groups
+}
@@ -0,0 +1,101 @@
+package odoo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// RunResult holds the outcome of an odoo-bin run
+type RunResult struct {
+	Records  []*LogRecord
+	ExitCode int
+}
+
+// Errors returns the ERROR and CRITICAL records of the run
+func (r *RunResult) Errors() []*LogRecord {
+	var errs []*LogRecord
+	for _, rec := range r.Records {
+		if LevelValue(rec.Level) >= LevelValue("ERROR") {
+			errs = append(errs, rec)
+		}
+	}
+	return errs
+}
+
+// FirstTraceback returns the first traceback logged during the run,
+// preferring those attached to error records
+func (r *RunResult) FirstTraceback() string {
+	if tb := FirstTraceback(r.Errors()); tb != "" {
+		return tb
+	}
+	return FirstTraceback(r.Records)
+}
+
+// Run executes odoo-bin with args, copying its output to echo (if not nil)
+// and parsing it into log records. Odoo must log to stderr/stdout, so
+// callers should not let odoo.conf redirect the log to a file.
+func Run(ctx context.Context, odooBin string, args []string, echo io.Writer) (*RunResult, error) {
+	return run(exec.CommandContext(ctx, odooBin, args...), echo)
+}
+
+// RunShell executes a Python script inside `odoo-bin shell` with args
+// (usually -c and -d), parsing the output like Run.
+func RunShell(ctx context.Context, odooBin string, args []string, script string, echo io.Writer) (*RunResult, error) {
+	shellArgs := append([]string{"shell"}, args...)
+	shellArgs = append(shellArgs, "--no-http")
+	cmd := exec.CommandContext(ctx, odooBin, shellArgs...)
+	cmd.Stdin = strings.NewReader(script)
+	return run(cmd, echo)
+}
+
+func run(cmd *exec.Cmd, echo io.Writer) (*RunResult, error) {
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	result := &RunResult{}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		parser := &LogParser{}
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if echo != nil {
+				fmt.Fprintln(echo, line)
+			}
+			if rec := parser.Feed(line); rec != nil {
+				result.Records = append(result.Records, rec)
+			}
+		}
+		if rec := parser.Flush(); rec != nil {
+			result.Records = append(result.Records, rec)
+		}
+		io.Copy(io.Discard, pr)
+	}()
+
+	if err := cmd.Start(); err != nil {
+		pw.Close()
+		wg.Wait()
+		return nil, fmt.Errorf("failed to start Odoo: %w", err)
+	}
+	err := cmd.Wait()
+	pw.Close()
+	wg.Wait()
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		result.ExitCode = exitErr.ExitCode()
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("Odoo execution failed: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,294 @@
+package odoo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	testStartRe  = regexp.MustCompile(`^Starting (\S+) \.\.\.`)
+	testFailRe   = regexp.MustCompile(`^(FAIL|ERROR): (.+)$`)
+	testSkipRe   = regexp.MustCompile(`^skipped (\S+)`)
+	testLoggerRe = regexp.MustCompile(`^odoo\.addons\.(\w+)\.tests(?:\.(\w+))?`)
+	// testSummaryRe matches the totals logged after the tests of a module
+	// or of the whole run, which repeat failures already reported
+	testSummaryRe = regexp.MustCompile(`^(\d+ failed, \d+ error\(s\) of \d+ tests|Module \S+: \d+ failures, \d+ errors of \d+ tests)`)
+)
+
+// outsideTestsID names the pseudo test holding errors logged between tests
+const outsideTestsID = "(outside of tests)"
+
+// Test outcomes
+const (
+	TestPassed  = "passed"
+	TestFailed  = "failure"
+	TestErrored = "error"
+	TestSkipped = "skipped"
+)
+
+// TestCase is the result of a single test method
+type TestCase struct {
+	Module    string
+	ClassName string
+	Name      string
+	Status    string
+	Message   string
+	Details   string
+	Duration  time.Duration
+	start     time.Time
+}
+
+// TestReport aggregates the test cases found in an Odoo test run log
+type TestReport struct {
+	Cases []*TestCase
+}
+
+// Count returns the number of cases with the given status
+func (r *TestReport) Count(status string) int {
+	n := 0
+	for _, c := range r.Cases {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed reports whether any case failed or errored
+func (r *TestReport) Failed() bool {
+	return r.Count(TestFailed) > 0 || r.Count(TestErrored) > 0
+}
+
+// Modules returns the module names with test cases, sorted
+func (r *TestReport) Modules() []string {
+	seen := make(map[string]bool)
+	var modules []string
+	for _, c := range r.Cases {
+		if !seen[c.Module] {
+			seen[c.Module] = true
+			modules = append(modules, c.Module)
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// ParseTestResults builds a report from the log records of an odoo-bin
+// --test-enable run. Tests are identified by their "Starting X ..." record
+// and end with their outcome or when another module's records follow;
+// FAIL/ERROR records and errors logged by the test while it runs mark it
+// failed. Other errors are reported under a pseudo test.
+func ParseTestResults(records []*LogRecord) *TestReport {
+	report := &TestReport{}
+	byID := make(map[string]*TestCase)
+	var current *TestCase
+
+	finish := func(at time.Time) {
+		if current != nil && !at.IsZero() && current.Duration == 0 {
+			current.Duration = at.Sub(current.start)
+		}
+		current = nil
+	}
+	caseFor := func(rec *LogRecord, id string) *TestCase {
+		if c, ok := byID[id]; ok {
+			return c
+		}
+		module, file := testModule(rec.Logger)
+		className, name := splitTestID(id)
+		if file != "" && className != "" {
+			className = fmt.Sprintf("odoo.addons.%s.tests.%s.%s", module, file, className)
+		}
+		c := &TestCase{Module: module, ClassName: className, Name: name, Status: TestPassed, start: rec.Time}
+		byID[id] = c
+		report.Cases = append(report.Cases, c)
+		return c
+	}
+
+	for _, rec := range records {
+		testLogger := isTestLogger(rec.Logger)
+		if m := testStartRe.FindStringSubmatch(rec.Message); m != nil && testLogger {
+			finish(rec.Time)
+			current = caseFor(rec, m[1])
+			continue
+		}
+		if m := testSkipRe.FindStringSubmatch(rec.Message); m != nil && testLogger {
+			c := caseFor(rec, m[1])
+			c.Status = TestSkipped
+			c.Message = strings.TrimLeft(strings.TrimPrefix(rec.Message, m[0]), " :")
+			if c == current {
+				finish(rec.Time)
+			}
+			continue
+		}
+		// The records of another module, or of the module loading, come
+		// after the running test has ended
+		if current != nil && (strings.HasPrefix(rec.Logger, "odoo.modules.") ||
+			(testLogger && testModuleName(rec.Logger) != current.Module)) {
+			finish(rec.Time)
+		}
+		if LevelValue(rec.Level) < LevelValue("ERROR") {
+			continue
+		}
+		if testSummaryRe.MatchString(rec.Message) && report.Failed() {
+			continue
+		}
+
+		status, message := TestErrored, rec.Message
+		var target *TestCase
+		if testLogger {
+			target = current
+		}
+		outcome := false
+		if m := testFailRe.FindStringSubmatch(rec.Message); m != nil && testLogger {
+			if m[1] == "FAIL" {
+				status = TestFailed
+			}
+			id := strings.TrimSpace(m[2])
+			if c, ok := byID[id]; ok {
+				target = c
+			} else if target == nil || !strings.HasSuffix(id, target.Name) {
+				target = caseFor(rec, id)
+			}
+			outcome = true
+		}
+		if target == nil {
+			target = caseFor(rec, outsideTestsID+" "+rec.Logger)
+		}
+		if target.Status == TestPassed || target.Status == TestSkipped {
+			target.Status = status
+			target.Message = message
+			target.Details = strings.Join(rec.RawLines, "\n")
+		} else {
+			target.Details += "\n" + strings.Join(rec.RawLines, "\n")
+		}
+		if outcome && target == current {
+			finish(rec.Time)
+		}
+	}
+	if len(records) > 0 {
+		finish(records[len(records)-1].Time)
+	}
+	return report
+}
+
+func isTestLogger(logger string) bool {
+	return testLoggerRe.MatchString(logger) || strings.HasPrefix(logger, "odoo.tests")
+}
+
+// testModule extracts module and test file from a test logger name
+func testModule(logger string) (string, string) {
+	if m := testLoggerRe.FindStringSubmatch(logger); m != nil {
+		return m[1], m[2]
+	}
+	return logger, ""
+}
+
+// testModuleName returns the module of a test logger name
+func testModuleName(logger string) string {
+	module, _ := testModule(logger)
+	return module
+}
+
+// splitTestID splits "Class.test_method" into class and method
+func splitTestID(id string) (string, string) {
+	if strings.Contains(id, " ") {
+		return "", id
+	}
+	if i := strings.LastIndex(id, "."); i > 0 {
+		return id[:i], id[i+1:]
+	}
+	return "", id
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Body    string `xml:",cdata"`
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes the report as JUnit XML, one testsuite per module
+func (r *TestReport) WriteJUnit(w io.Writer, total time.Duration) error {
+	root := junitTestSuites{Time: seconds(total)}
+	for _, module := range r.Modules() {
+		suite := junitTestSuite{Name: module}
+		var suiteTime time.Duration
+		for _, c := range r.Cases {
+			if c.Module != module {
+				continue
+			}
+			tc := junitTestCase{ClassName: c.ClassName, Name: c.Name, Time: seconds(c.Duration)}
+			if tc.ClassName == "" {
+				tc.ClassName = "odoo.addons." + module
+			}
+			msg := &junitMessage{Message: c.Message, Body: c.Details}
+			switch c.Status {
+			case TestFailed:
+				tc.Failure = msg
+				suite.Failures++
+			case TestErrored:
+				tc.Error = msg
+				suite.Errors++
+			case TestSkipped:
+				tc.Skipped = &junitMessage{Message: c.Message}
+				suite.Skipped++
+			}
+			suite.Tests++
+			suiteTime += c.Duration
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Time = seconds(suiteTime)
+		root.Tests += suite.Tests
+		root.Failures += suite.Failures
+		root.Errors += suite.Errors
+		root.Skipped += suite.Skipped
+		root.Suites = append(root.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
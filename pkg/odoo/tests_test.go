@@ -0,0 +1,60 @@
+package odoo
+
+import (
+	"testing"
+	"time"
+)
+
+func testRecord(at time.Time, level, logger, message string) *LogRecord {
+	return &LogRecord{Time: at, Level: level, Logger: logger, Message: message, RawLines: []string{message}}
+}
+
+func TestParseTestResultsSummaryAfterPassingTest(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	logger := "odoo.addons.sale_custom.tests.test_sale"
+	records := []*LogRecord{
+		testRecord(start, "INFO", logger, "Starting TestSale.test_confirm ..."),
+		testRecord(start.Add(2*time.Second), "INFO", logger, "Starting TestSale.test_cancel ..."),
+		testRecord(start.Add(3*time.Second), "ERROR", logger, "FAIL: TestSale.test_cancel"),
+		testRecord(start.Add(4*time.Second), "INFO", logger, "Starting TestSale.test_invoice ..."),
+		testRecord(start.Add(5*time.Second), "ERROR", "odoo.modules.loading", "Module sale_custom: 1 failures, 0 errors of 3 tests"),
+		testRecord(start.Add(6*time.Second), "ERROR", "odoo.tests.result", "1 failed, 0 error(s) of 3 tests when loading database 'test'"),
+	}
+
+	report := ParseTestResults(records)
+	if len(report.Cases) != 3 {
+		t.Fatalf("got %d cases, want 3", len(report.Cases))
+	}
+	want := map[string]string{
+		"test_confirm": TestPassed,
+		"test_cancel":  TestFailed,
+		"test_invoice": TestPassed,
+	}
+	for _, c := range report.Cases {
+		if c.Status != want[c.Name] {
+			t.Errorf("%s: got status %s, want %s (details %q)", c.Name, c.Status, want[c.Name], c.Details)
+		}
+	}
+	if d := report.Cases[2].Duration; d != time.Second {
+		t.Errorf("test_invoice: got duration %s, want 1s", d)
+	}
+}
+
+func TestParseTestResultsErrorOutsideTests(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	records := []*LogRecord{
+		testRecord(start, "INFO", "odoo.addons.sale_custom.tests.test_sale", "Starting TestSale.test_confirm ..."),
+		testRecord(start.Add(time.Second), "ERROR", "odoo.modules.registry", "Failed to load registry"),
+	}
+
+	report := ParseTestResults(records)
+	if len(report.Cases) != 2 {
+		t.Fatalf("got %d cases, want 2", len(report.Cases))
+	}
+	if c := report.Cases[0]; c.Status != TestPassed {
+		t.Errorf("%s: got status %s, want %s", c.Name, c.Status, TestPassed)
+	}
+	if c := report.Cases[1]; c.Status != TestErrored || c.Module != "odoo.modules.registry" {
+		t.Errorf("got %s in %s, want an error outside of tests", c.Status, c.Module)
+	}
+}
@@ -0,0 +1,102 @@
+package odoo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	versionInfoRe = regexp.MustCompile(`(?m)^version_info\s*=\s*\(\s*(?:'saas~)?(\d+)'?\s*,\s*(\d+)`)
+	majorRe       = regexp.MustCompile(`^(\d+)`)
+)
+
+// DetectVersion reads odoo/release.py next to odoo-bin and returns the
+// Odoo series (e.g. "17.0").
+func DetectVersion(odooBin string) (string, error) {
+	releaseFile := filepath.Join(filepath.Dir(odooBin), "odoo", "release.py")
+	content, err := os.ReadFile(releaseFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", releaseFile, err)
+	}
+
+	m := versionInfoRe.FindSubmatch(content)
+	if m == nil {
+		return "", fmt.Errorf("version_info not found in %s", releaseFile)
+	}
+	return fmt.Sprintf("%s.%s", m[1], m[2]), nil
+}
+
+// MajorVersion returns the major number of a series like "17.0", 0 if unknown
+func MajorVersion(version string) int {
+	m := majorRe.FindStringSubmatch(version)
+	if m == nil {
+		return 0
+	}
+	major, _ := strconv.Atoi(m[1])
+	return major
+}
+
+// SeriesOf returns the series prefix of a full module version, e.g. "17.0"
+// for "17.0.1.2.0"
+func SeriesOf(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// AdaptVersion prefixes a manifest version with the series, as Odoo does
+// when it stores latest_version. A missing version defaults to 1.0.
+func AdaptVersion(version, series string) string {
+	if version == "" {
+		version = "1.0"
+	}
+	if series == "" {
+		return version
+	}
+	if version == series || !strings.HasPrefix(version, series+".") {
+		return series + "." + version
+	}
+	return version
+}
+
+// CompareVersions compares dotted versions part by part, numerically when
+// both parts are numbers. Missing parts count as zero, so 1.0 equals 1.0.0,
+// as in Odoo's parse_version. It returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	pa := strings.Split(a, ".")
+	pb := strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		x, y := "0", "0"
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		nx, errx := strconv.Atoi(x)
+		ny, erry := strconv.Atoi(y)
+		if errx == nil && erry == nil {
+			if nx != ny {
+				return cmpInt(nx, ny)
+			}
+			continue
+		}
+		if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func cmpInt(a, b int) int {
+	if a < b {
+		return -1
+	}
+	return 1
+}
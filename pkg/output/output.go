@@ -0,0 +1,115 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Supported output formats
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// Formats lists every format accepted by the --output flag
+var Formats = []string{FormatTable, FormatJSON, FormatCSV}
+
+// Table is a tabular result shared by all listing commands
+type Table struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// NewTable creates an empty table with the given column headers
+func NewTable(headers ...string) *Table {
+	return &Table{Headers: headers}
+}
+
+// AddRow appends a row; values are formatted with fmt.Sprint
+func (t *Table) AddRow(values ...interface{}) {
+	row := make([]string, len(values))
+	for i, v := range values {
+		row[i] = fmt.Sprint(v)
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+// AddFormatFlag registers the --output/-o flag on cmd
+func AddFormatFlag(cmd *cobra.Command, format *string) {
+	cmd.Flags().StringVarP(format, "output", "o", FormatTable,
+		"Output format ("+strings.Join(Formats, ", ")+")")
+}
+
+// ValidFormat reports whether format is supported
+func ValidFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Render writes the table to w in the requested format
+func Render(w io.Writer, format string, t *Table) error {
+	switch format {
+	case FormatTable, "":
+		return renderTable(w, t)
+	case FormatJSON:
+		return renderJSON(w, t)
+	case FormatCSV:
+		return renderCSV(w, t)
+	default:
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+}
+
+func renderTable(w io.Writer, t *Table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.Headers, "\t"))
+	for _, row := range t.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// renderJSON emits an array of objects keyed by lower-cased header
+func renderJSON(w io.Writer, t *Table) error {
+	keys := make([]string, len(t.Headers))
+	for i, h := range t.Headers {
+		keys[i] = strings.ReplaceAll(strings.ToLower(h), " ", "_")
+	}
+
+	items := make([]map[string]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		item := make(map[string]string, len(keys))
+		for i, key := range keys {
+			if i < len(row) {
+				item[key] = row[i]
+			}
+		}
+		items = append(items, item)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
+
+func renderCSV(w io.Writer, t *Table) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Headers); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
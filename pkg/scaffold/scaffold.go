@@ -0,0 +1,186 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/mjavint/ocli/pkg/odoo"
+)
+
+// DefaultTemplate is used when no template is requested
+const DefaultTemplate = "default"
+
+// templateExt marks the files rendered with text/template; other files
+// are copied verbatim
+const templateExt = ".tmpl"
+
+//go:embed all:templates
+var builtin embed.FS
+
+// Data is passed to every template, both for file names and contents
+type Data struct {
+	// Name is the technical module name, e.g. sale_custom
+	Name string
+	// Title is the human readable name, e.g. Sale Custom
+	Title string
+	// Model is the model of the module, e.g. sale.custom
+	Model string
+	// ModelID is the model in xml ids, e.g. sale_custom
+	ModelID string
+	// ModelClass is the Python class name, e.g. SaleCustom
+	ModelClass string
+	Author     string
+	License    string
+	Website    string
+	// Series is the Odoo series, e.g. 17.0, empty if unknown
+	Series string
+	// Version is the module version with the series prefix
+	Version string
+	// ListView is "list" since Odoo 18 and "tree" before
+	ListView string
+}
+
+// DefaultLicense is used when no license is configured
+const DefaultLicense = "LGPL-3"
+
+// NewData derives the template data of module name
+func NewData(name, series, author, license, website string) Data {
+	if license == "" {
+		license = DefaultLicense
+	}
+	words := strings.Split(name, "_")
+	var title, class []string
+	for _, w := range words {
+		if w == "" {
+			continue
+		}
+		word := strings.ToUpper(w[:1]) + w[1:]
+		title = append(title, word)
+		class = append(class, word)
+	}
+	d := Data{
+		Name:       name,
+		Title:      strings.Join(title, " "),
+		Model:      strings.ReplaceAll(name, "_", "."),
+		ModelID:    name,
+		ModelClass: strings.Join(class, ""),
+		Author:     author,
+		License:    license,
+		Website:    website,
+		Series:     series,
+		Version:    "1.0.0",
+		ListView:   "tree",
+	}
+	if series != "" {
+		d.Version = series + ".1.0.0"
+		if odoo.MajorVersion(series) >= 18 {
+			d.ListView = "list"
+		}
+	}
+	return d
+}
+
+// Templates returns the available template names: the builtin ones and
+// the custom directories, which take precedence
+func Templates(custom map[string]string) []string {
+	seen := make(map[string]bool)
+	entries, _ := fs.ReadDir(builtin, "templates")
+	for _, e := range entries {
+		if e.IsDir() {
+			seen[e.Name()] = true
+		}
+	}
+	for name := range custom {
+		seen[name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templateFS returns the file tree of a template
+func templateFS(name string, custom map[string]string) (fs.FS, error) {
+	if dir, ok := custom[name]; ok {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("template directory %s of %s not found", dir, name)
+		}
+		return os.DirFS(dir), nil
+	}
+	if _, err := fs.Stat(builtin, path.Join("templates", name)); err != nil {
+		return nil, fmt.Errorf("unknown template %s", name)
+	}
+	return fs.Sub(builtin, path.Join("templates", name))
+}
+
+// Render writes template tmpl into dest, which must not exist yet, and
+// returns the files created
+func Render(tmpl string, custom map[string]string, dest string, data Data) ([]string, error) {
+	src, err := templateFS(tmpl, custom)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return nil, fmt.Errorf("%s already exists", dest)
+	}
+
+	var created []string
+	err = fs.WalkDir(src, ".", func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := renderString(p, p, data)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, filepath.FromSlash(rel))
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		content, err := fs.ReadFile(src, p)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(target, templateExt) {
+			target = strings.TrimSuffix(target, templateExt)
+			rendered, err := renderString(p, string(content), data)
+			if err != nil {
+				return err
+			}
+			content = []byte(rendered)
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return err
+		}
+		created = append(created, target)
+		return nil
+	})
+	if err != nil {
+		os.RemoveAll(dest)
+		return nil, fmt.Errorf("failed to render template %s: %w", tmpl, err)
+	}
+	return created, nil
+}
+
+func renderString(name, text string, data Data) (string, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -2,7 +2,10 @@ package utils
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 )
 
 func GetBackupFilePath(backupDir, dbName, backupFormat string, noFilestore bool) string {
@@ -14,3 +17,47 @@ func GetBackupFilePath(backupDir, dbName, backupFormat string, noFilestore bool)
 	}
 	return fmt.Sprintf("%s/%s.%s", backupDir, dbName, backupFormat)
 }
+
+// CopyTree copies the directory src into dst, hard-linking files when
+// possible. Odoo filestore files are content-addressed and never modified
+// in place, so links are safe for them.
+func CopyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if err := os.Link(path, target); err == nil {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}